@@ -1,31 +1,72 @@
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Status conditions
 const (
-	BoundCondition = "Bound"
+	BoundCondition     = "Bound"
+	ConflictCondition  = "Conflict"
+	ReadyCondition     = "Ready"
+	DriftedCondition   = "Drifted"
+	SuspendedCondition = "Suspended"
 )
 
 // Status reasons
 const (
-	VaultConnectionFailedReason = "VaultConnectionFailed"
-	VaultUpdateFailedReason     = "VaultUpdateFailed"
-	VaultUpdateSuccessfulReason = "VaultUpdateSuccessful"
-	VaultReadSourceFailedReason = "VaultReadSourceFailed"
-	SecretNotFoundReason        = "SecretNotFoundFailed"
+	VaultConnectionFailedReason  = "VaultConnectionFailed"
+	VaultUpdateFailedReason      = "VaultUpdateFailed"
+	VaultUpdateConflictReason    = "VaultUpdateConflict"
+	VaultUpdateSuccessfulReason  = "VaultUpdateSuccessful"
+	VaultReadSourceFailedReason  = "VaultReadSourceFailed"
+	SecretNotFoundReason         = "SecretNotFoundFailed"
+	FieldConflictReason          = "FieldConflict"
+	SecretUpdateFailedReason     = "SecretUpdateFailed"
+	SecretUpdateSuccessfulReason = "SecretUpdateSuccessful"
+	VaultSealedReason            = "VaultSealed"
+	VaultHealthyReason           = "VaultHealthy"
+	TLSConfigInvalidReason       = "TLSConfigInvalid"
+	FieldsDriftedReason          = "FieldsDrifted"
+	DriftCorrectedReason         = "DriftCorrected"
+	SuspendedReason              = "ReconciliationSuspended"
 )
 
+// ReconcileRequestAnnotation triggers an immediate reconciliation once its value changes, for example to the current time
+const ReconcileRequestAnnotation = "reconcile.vault.infra.doodle.com/requestedAt"
+
+// ReconcileRequestStatus holds the last handled reconcile request
+type ReconcileRequestStatus struct {
+	// LastHandledReconcileAt is the last handled value of the reconcile.vault.infra.doodle.com/requestedAt annotation
+	// +optional
+	LastHandledReconcileAt string `json:"lastHandledReconcileAt,omitempty"`
+}
+
+// ReconcileRequestedAt returns the value of the reconcile request annotation
+func ReconcileRequestedAt(obj metav1.Object) (string, bool) {
+	v, ok := obj.GetAnnotations()[ReconcileRequestAnnotation]
+	return v, ok
+}
+
 // VaultSpec defines how to connect to a vault
 type VaultSpec struct {
+	// ConnectionRef references a VaultConnection or ClusterVaultConnection.
+	// If set address, namespace, tlsConfig and auth are taken from the connection.
+	// +optional
+	ConnectionRef *VaultConnectionReference `json:"connectionRef,omitempty"`
+
 	// The http URL for the vault server
 	// By default the global VAULT_ADDRESS gets used.
 	// +optional
 	Address string `json:"address,omitempty"`
 
+	// Namespace is the vault enterprise namespace the path belongs to.
+	// By default the global VAULT_NAMESPACE gets used.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
 	// Vault TLS configuration
 	// +optional
 	TLSConfig VaultTLSSpec `json:"tlsConfig"`
@@ -37,41 +78,153 @@ type VaultSpec struct {
 	// The vault path, for example: /secret/myapp
 	// +required
 	Path string `json:"path"`
+
+	// KVVersion is the version of the kv secrets engine mounted at the path.
+	// By default the version gets detected from the mount. If set no detection happens,
+	// for kv version 2 the mount is expected to be the first element of the path.
+	// +kubebuilder:validation:Enum=1;2
+	// +optional
+	KVVersion int `json:"kvVersion,omitempty"`
 }
 
 // VaultAuthSpec is the confuguration for vault authentication which by default
-// is kubernetes auth (And the only supported one in the current state)
+// is kubernetes auth
 type VaultAuthSpec struct {
 	// Type is by default kubernetes authentication. The vault needs to be equipped with
-	// the kubernetes auth method. Currently only kubernetes is supported.
+	// the kubernetes auth method. Supported are kubernetes, approle, token, jwt and cert.
+	// +kubebuilder:validation:Enum=kubernetes;approle;token;jwt;cert
 	// +optional
 	Type string `json:"type,omitempty"`
 
+	// Namespace is the vault enterprise namespace the auth method is mounted in.
+	// By default the namespace of the vault spec gets used.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// MountPath is the path the auth method is mounted at, for example /auth/k8s-prod.
+	// By default the auth method is expected at /auth/<type>.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
 	// TokenPath allows to use a different token path used for kubernetes authentication.
 	// +optional
 	TokenPath string `json:"tokenPath,omitempty"`
 
 	// Role is used to map the kubernetes serviceAccount to a vault role.
 	// A default VAULT_ROLE might be set for the controller. If neither is set
-	// the VaultMirror can not authenticate.
+	// the VaultMirror can not authenticate using kubernetes authentication.
+	// For jwt authentication the default role of the auth mount gets used.
+	// For cert authentication the role is the optional name of the certificate role to authenticate against.
 	// +optional
 	Role string `json:"role,omitempty"`
+
+	// AppRole holds the credentials used for approle authentication.
+	// +optional
+	AppRole *VaultAppRoleSpec `json:"appRole,omitempty"`
+
+	// Token references a static vault token used for token authentication.
+	// +optional
+	Token *VaultTokenSpec `json:"token,omitempty"`
+
+	// JWT configures the source of the service account token used for jwt authentication.
+	// +optional
+	JWT *VaultJWTSpec `json:"jwt,omitempty"`
+}
+
+// VaultAppRoleSpec references the approle credentials
+type VaultAppRoleSpec struct {
+	// SecretRef is the kubernetes secret which holds the role_id and secret_id.
+	// The secret must be in the same namespace as the resource, for a ClusterVaultConnection in the controller namespace.
+	// +required
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+
+	// RoleIDKey is the secret key which holds the role_id, by default role_id.
+	// +optional
+	RoleIDKey string `json:"roleIDKey,omitempty"`
+
+	// SecretIDKey is the secret key which holds the secret_id, by default secret_id.
+	// +optional
+	SecretIDKey string `json:"secretIDKey,omitempty"`
+}
+
+// VaultTokenSpec references a static vault token
+type VaultTokenSpec struct {
+	// SecretRef references the kubernetes secret key which holds the token.
+	// The secret must be in the same namespace as the resource, for a ClusterVaultConnection in the controller namespace.
+	// +optional
+	SecretRef *corev1.SecretKeySelector `json:"secretRef,omitempty"`
+
+	// Path is a file on the controller pod which holds the token.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// VaultJWTSpec configures the service account token used for jwt authentication.
+// By default the service account token of the controller gets used.
+type VaultJWTSpec struct {
+	// TokenPath is a projected service account token file on the controller pod.
+	// +optional
+	TokenPath string `json:"tokenPath,omitempty"`
+
+	// ServiceAccountName requests a token for the service account in the namespace of the resource,
+	// for a ClusterVaultConnection in the controller namespace, using the kubernetes TokenRequest API instead of reading a token file.
+	// Token requests must be enabled on the controller with --token-request-audiences.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Audiences of the token requested using the TokenRequest API.
+	// They must be allowed by the controller with --token-request-audiences, by default the token is issued for all allowed audiences.
+	// +optional
+	Audiences []string `json:"audiences,omitempty"`
+
+	// ExpirationSeconds of the token requested using the TokenRequest API, by default 600.
+	// +kubebuilder:validation:Minimum=600
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
 }
 
 // VaultTLSSpec Vault TLS options
 type VaultTLSSpec struct {
+	// CACert is the path to a PEM-encoded CA certificate file on the controller pod.
 	// +optional
 	CACert string `json:"caCert,omitempty"`
 
+	// CACertPEM is an inline PEM-encoded CA certificate bundle.
+	// +optional
+	CACertPEM string `json:"caCertPEM,omitempty"`
+
+	// CACertSecretRef references a secret key which holds a PEM-encoded CA certificate bundle.
+	// +optional
+	CACertSecretRef *corev1.SecretKeySelector `json:"caCertSecretRef,omitempty"`
+
+	// CACertConfigMapRef references a config map key which holds a PEM-encoded CA certificate bundle.
+	// +optional
+	CACertConfigMapRef *corev1.ConfigMapKeySelector `json:"caCertConfigMapRef,omitempty"`
+
+	// CAPath is the path to a directory of PEM-encoded CA certificate files on the controller pod.
 	// +optional
 	CAPath string `json:"caPath,omitempty"`
 
+	// ClientCert is the path to a PEM-encoded client certificate file on the controller pod.
 	// +optional
 	ClientCert string `json:"clientCert,omitempty"`
 
+	// ClientCertPEM is an inline PEM-encoded client certificate.
+	// +optional
+	ClientCertPEM string `json:"clientCertPEM,omitempty"`
+
+	// ClientCertSecretRef references a secret key which holds a PEM-encoded client certificate.
+	// +optional
+	ClientCertSecretRef *corev1.SecretKeySelector `json:"clientCertSecretRef,omitempty"`
+
+	// ClientKey is the path to a PEM-encoded client key file on the controller pod.
 	// +optional
 	ClientKey string `json:"clientKey,omitempty"`
 
+	// ClientKeySecretRef references a secret key which holds a PEM-encoded client key.
+	// +optional
+	ClientKeySecretRef *corev1.SecretKeySelector `json:"clientKeySecretRef,omitempty"`
+
 	// +optional
 	ServerName string `json:"serverName,omitempty"`
 
@@ -79,6 +232,17 @@ type VaultTLSSpec struct {
 	Insecure bool `json:"insecure,omitempty"`
 }
 
+// VaultTLSStatus holds the expiry of the certificates used to connect to vault
+type VaultTLSStatus struct {
+	// CACertExpiry is the earliest expiry of the CA certificates
+	// +optional
+	CACertExpiry *metav1.Time `json:"caCertExpiry,omitempty"`
+
+	// ClientCertExpiry is the expiry of the client certificate
+	// +optional
+	ClientCertExpiry *metav1.Time `json:"clientCertExpiry,omitempty"`
+}
+
 // FieldMapping maps a secret field to the vault path
 type FieldMapping struct {
 	// Name is the kubernetes secret field name
@@ -109,3 +273,8 @@ func setResourceCondition(resource conditionalResource, condition string, status
 
 	apimeta.SetStatusCondition(conditions, newCondition)
 }
+
+// removeResourceCondition removes the given condition from a resource.
+func removeResourceCondition(resource conditionalResource, condition string) {
+	apimeta.RemoveStatusCondition(resource.GetStatusConditions(), condition)
+}
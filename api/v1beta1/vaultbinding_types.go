@@ -36,8 +36,44 @@ type VaultBindingSpec struct {
 	// The kubernetes secret the VaultBinding is referring to
 	// +required
 	Secret *corev1.SecretReference `json:"secret"`
+
+	// Prune removes fields from vault which were previously written by the binding
+	// but are not mapped anymore, either because they were removed from the field mapping or from the secret.
+	// +optional
+	Prune bool `json:"prune,omitempty"`
+
+	// DeletionPolicy defines what happens to the fields written to vault once the VaultBinding gets deleted.
+	// Retain keeps them in vault, Delete removes them and deletes the path if no fields are left.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +kubebuilder:default=Retain
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Vault does not provide a watch api, with an interval the controller re-reads the vault path periodically
+	// to detect fields which were changed or deleted in vault.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Suspend pauses the reconciliation of the binding, changes to the binding or its secret are not written to vault.
+	// Fields are still removed from vault if the binding gets deleted.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
+// DeletionPolicy defines how vault fields are handled once a VaultBinding gets deleted
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRetain keeps the fields in vault
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+
+	// DeletionPolicyDelete removes the fields from vault
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+)
+
+// Finalizer is set on resources which require cleanup in vault before they can be deleted
+const Finalizer = "finalizers.vault.infra.doodle.com"
+
 func (in *VaultBindingSpec) IsForceApply() bool {
 	return in.ForceApply
 }
@@ -59,8 +95,17 @@ type VaultBindingStatus struct {
 	// ObservedGeneration is the last generation reconciled by the controller
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
-	// Vault Status (not implemented yet)
-	Vault VaultBindingVaultStatus `json:",inline"`
+	// ManagedFields are the vault fields which hold the value mapped by the binding
+	// +optional
+	ManagedFields []string `json:"managedFields,omitempty"`
+
+	// TLS holds the expiry of the certificates used to connect to vault
+	// +optional
+	TLS *VaultTLSStatus `json:"tls,omitempty"`
+
+	VaultBindingVaultStatus `json:",inline"`
+
+	ReconcileRequestStatus `json:",inline"`
 }
 
 // VaultBindingNotBound de
@@ -75,15 +120,76 @@ func VaultBindingBound(binding VaultBinding, reason, message string) VaultBindin
 	return binding
 }
 
+// VaultBindingConflict sets the Conflict condition if fields are owned by other resources
+func VaultBindingConflict(binding VaultBinding, reason, message string) VaultBinding {
+	setResourceCondition(&binding, ConflictCondition, metav1.ConditionTrue, reason, message)
+	return binding
+}
+
+// VaultBindingNoConflict removes the Conflict condition
+func VaultBindingNoConflict(binding VaultBinding) VaultBinding {
+	removeResourceCondition(&binding, ConflictCondition)
+	return binding
+}
+
+// VaultBindingDrifted sets the Drifted condition if managed fields were changed in vault
+func VaultBindingDrifted(binding VaultBinding, status metav1.ConditionStatus, reason, message string) VaultBinding {
+	setResourceCondition(&binding, DriftedCondition, status, reason, message)
+	return binding
+}
+
+// VaultBindingNotDrifted removes the Drifted condition
+func VaultBindingNotDrifted(binding VaultBinding) VaultBinding {
+	removeResourceCondition(&binding, DriftedCondition)
+	return binding
+}
+
+// VaultBindingSuspended sets the Suspended condition
+func VaultBindingSuspended(binding VaultBinding, message string) VaultBinding {
+	setResourceCondition(&binding, SuspendedCondition, metav1.ConditionTrue, SuspendedReason, message)
+	return binding
+}
+
+// VaultBindingNotSuspended removes the Suspended condition
+func VaultBindingNotSuspended(binding VaultBinding) VaultBinding {
+	removeResourceCondition(&binding, SuspendedCondition)
+	return binding
+}
+
 // GetStatusConditions returns a pointer to the Status.Conditions slice
 func (in *VaultBinding) GetStatusConditions() *[]metav1.Condition {
 	return &in.Status.Conditions
 }
 
+// VaultBindingVaultStatus describes what was written to vault during the last sync
 type VaultBindingVaultStatus struct {
+	// Address is the effective vault address including the default VAULT_ADDR
+	// +optional
 	Address string `json:"address,omitempty"`
-	Path    string `json:"path,omitempty"`
-	Fields  string `json:"fields,omitempty"`
+
+	// Path is the vault path written to, for kv version 2 mounts the data path
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Fields is a comma separated list of the destination fields holding the mapped values
+	// +optional
+	Fields string `json:"fields,omitempty"`
+
+	// KVVersion is the kv version of the vault mount
+	// +optional
+	KVVersion int `json:"kvVersion,omitempty"`
+
+	// Version is the kv version 2 secret version after the last sync
+	// +optional
+	Version int `json:"version,omitempty"`
+
+	// DataHash is the HMAC-SHA256 of the fields and their values keyed with the controller hash key
+	// +optional
+	DataHash string `json:"dataHash,omitempty"`
+
+	// LastSyncTime is the time of the last successful sync
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -91,6 +197,10 @@ type VaultBindingVaultStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Bound\")].status",description=""
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Bound\")].message",description=""
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".status.address",description="",priority=1
+// +kubebuilder:printcolumn:name="Path",type="string",JSONPath=".status.path",description="",priority=1
+// +kubebuilder:printcolumn:name="Fields",type="string",JSONPath=".status.fields",description="",priority=1
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime",description="",priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
 
 // VaultBinding is the Schema for the vaultbindings API
@@ -0,0 +1,198 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Kinds of vault connections
+const (
+	VaultConnectionKind        = "VaultConnection"
+	ClusterVaultConnectionKind = "ClusterVaultConnection"
+)
+
+// VaultConnectionSpec defines how to connect to a vault
+type VaultConnectionSpec struct {
+	// The http URL for the vault server
+	// By default the global VAULT_ADDRESS gets used.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Namespace is the vault enterprise namespace.
+	// By default the global VAULT_NAMESPACE gets used.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Vault TLS configuration
+	// +optional
+	TLSConfig VaultTLSSpec `json:"tlsConfig"`
+
+	// Vault authentication parameters
+	// +optional
+	Auth VaultAuthSpec `json:"auth,omitempty"`
+
+	// Interval in which the health of the vault server gets checked
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// VaultConnectionReference references a VaultConnection or ClusterVaultConnection
+type VaultConnectionReference struct {
+	// Kind of the connection, by default VaultConnection
+	// +kubebuilder:validation:Enum=VaultConnection;ClusterVaultConnection
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the connection. A VaultConnection must be in the same namespace.
+	// +required
+	Name string `json:"name"`
+}
+
+// VaultConnectionStatus defines the observed state of a vault connection
+type VaultConnectionStatus struct {
+	// Conditions holds the conditions for the connection.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Version of the vault server
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// ConnectionNotReady sets the Ready condition of a VaultConnection or ClusterVaultConnection to false
+func ConnectionNotReady(conn conditionalResource, reason, message string) {
+	setResourceCondition(conn, ReadyCondition, metav1.ConditionFalse, reason, message)
+}
+
+// ConnectionReady sets the Ready condition of a VaultConnection or ClusterVaultConnection to true
+func ConnectionReady(conn conditionalResource, reason, message string) {
+	setResourceCondition(conn, ReadyCondition, metav1.ConditionTrue, reason, message)
+}
+
+// VaultConnectionNotReady de
+func VaultConnectionNotReady(conn VaultConnection, reason, message string) VaultConnection {
+	setResourceCondition(&conn, ReadyCondition, metav1.ConditionFalse, reason, message)
+	return conn
+}
+
+// VaultConnectionReady de
+func VaultConnectionReady(conn VaultConnection, reason, message string) VaultConnection {
+	setResourceCondition(&conn, ReadyCondition, metav1.ConditionTrue, reason, message)
+	return conn
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *VaultConnection) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// GetConnectionSpec returns a pointer to the connection settings
+func (in *VaultConnection) GetConnectionSpec() *VaultConnectionSpec {
+	return &in.Spec
+}
+
+// GetConnectionStatus returns a pointer to the connection status
+func (in *VaultConnection) GetConnectionStatus() *VaultConnectionStatus {
+	return &in.Status
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=vc
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.address",description=""
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// VaultConnection is the Schema for the vaultconnections API
+type VaultConnection struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultConnectionSpec   `json:"spec,omitempty"`
+	Status VaultConnectionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultConnectionList contains a list of VaultConnection
+type VaultConnectionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultConnection `json:"items"`
+}
+
+// ClusterVaultConnectionNotReady de
+func ClusterVaultConnectionNotReady(conn ClusterVaultConnection, reason, message string) ClusterVaultConnection {
+	setResourceCondition(&conn, ReadyCondition, metav1.ConditionFalse, reason, message)
+	return conn
+}
+
+// ClusterVaultConnectionReady de
+func ClusterVaultConnectionReady(conn ClusterVaultConnection, reason, message string) ClusterVaultConnection {
+	setResourceCondition(&conn, ReadyCondition, metav1.ConditionTrue, reason, message)
+	return conn
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *ClusterVaultConnection) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// GetConnectionSpec returns a pointer to the connection settings
+func (in *ClusterVaultConnection) GetConnectionSpec() *VaultConnectionSpec {
+	return &in.Spec
+}
+
+// GetConnectionStatus returns a pointer to the connection status
+func (in *ClusterVaultConnection) GetConnectionStatus() *VaultConnectionStatus {
+	return &in.Status
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=cvc
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.address",description=""
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// ClusterVaultConnection is the Schema for the clustervaultconnections API
+type ClusterVaultConnection struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultConnectionSpec   `json:"spec,omitempty"`
+	Status VaultConnectionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterVaultConnectionList contains a list of ClusterVaultConnection
+type ClusterVaultConnectionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterVaultConnection `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultConnection{}, &VaultConnectionList{}, &ClusterVaultConnection{}, &ClusterVaultConnectionList{})
+}
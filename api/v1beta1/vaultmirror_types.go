@@ -34,6 +34,10 @@ type VaultMirrorSpec struct {
 	// +optional
 	Interval *metav1.Duration `json:"interval,omitempty"`
 
+	// Suspend pauses the reconciliation of the mirror including the interval
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
 	// By default existing matching fields in vault do not get overwritten
 	// +optional
 	ForceApply bool `json:"forceApply,omitempty"`
@@ -41,6 +45,20 @@ type VaultMirrorSpec struct {
 	// Define the secrets which must be mapped to vault
 	// +optional
 	Fields []FieldMapping `json:"fields,omitempty"`
+
+	// Recursive mirrors all secrets below the source path to the same relative path below the destination path
+	// +optional
+	Recursive bool `json:"recursive,omitempty"`
+
+	// Include only mirrors secrets matching any of the glob patterns if recursive is enabled.
+	// Patterns are matched against the path relative to the source path, a pattern matching a directory includes all secrets below.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude skips secrets matching any of the glob patterns if recursive is enabled.
+	// Patterns are matched against the path relative to the source path, a pattern matching a directory excludes all secrets below.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 // VaultMirrorStatus defines the observed state of VaultMirror
@@ -52,8 +70,21 @@ type VaultMirrorStatus struct {
 	// ObservedGeneration is the last generation reconciled by the controller
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
-	// Vault Status (not implemented yet)
-	Vault VaultMirrorVaultStatus `json:",inline"`
+	// ManagedFields are the vault fields which hold the value mapped by the mirror
+	// +optional
+	ManagedFields []string `json:"managedFields,omitempty"`
+
+	// SourceTLS holds the expiry of the certificates used to connect to the source vault
+	// +optional
+	SourceTLS *VaultTLSStatus `json:"sourceTLS,omitempty"`
+
+	// DestinationTLS holds the expiry of the certificates used to connect to the destination vault
+	// +optional
+	DestinationTLS *VaultTLSStatus `json:"destinationTLS,omitempty"`
+
+	VaultMirrorVaultStatus `json:",inline"`
+
+	ReconcileRequestStatus `json:",inline"`
 }
 
 func (in *VaultMirrorSpec) IsForceApply() bool {
@@ -80,15 +111,65 @@ func VaultMirrorBound(mirror VaultMirror, reason, message string) VaultMirror {
 	return mirror
 }
 
+// VaultMirrorConflict sets the Conflict condition if fields are owned by other resources
+func VaultMirrorConflict(mirror VaultMirror, reason, message string) VaultMirror {
+	setResourceCondition(&mirror, ConflictCondition, metav1.ConditionTrue, reason, message)
+	return mirror
+}
+
+// VaultMirrorNoConflict removes the Conflict condition
+func VaultMirrorNoConflict(mirror VaultMirror) VaultMirror {
+	removeResourceCondition(&mirror, ConflictCondition)
+	return mirror
+}
+
+// VaultMirrorSuspended sets the Suspended condition
+func VaultMirrorSuspended(mirror VaultMirror, message string) VaultMirror {
+	setResourceCondition(&mirror, SuspendedCondition, metav1.ConditionTrue, SuspendedReason, message)
+	return mirror
+}
+
+// VaultMirrorNotSuspended removes the Suspended condition
+func VaultMirrorNotSuspended(mirror VaultMirror) VaultMirror {
+	removeResourceCondition(&mirror, SuspendedCondition)
+	return mirror
+}
+
 // GetStatusConditions returns a pointer to the Status.Conditions slice
 func (in *VaultMirror) GetStatusConditions() *[]metav1.Condition {
 	return &in.Status.Conditions
 }
 
+// VaultMirrorVaultStatus describes what was written to the destination vault during the last sync
 type VaultMirrorVaultStatus struct {
+	// Address is the effective destination vault address including the default VAULT_ADDR
+	// +optional
 	Address string `json:"address,omitempty"`
-	Path    string `json:"path,omitempty"`
-	Fields  string `json:"fields,omitempty"`
+
+	// Path is the vault path written to, for kv version 2 mounts the data path.
+	// For recursive mirrors it is the destination base path.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Fields is a comma separated list of the destination fields holding the mapped values
+	// +optional
+	Fields string `json:"fields,omitempty"`
+
+	// KVVersion is the kv version of the vault mount
+	// +optional
+	KVVersion int `json:"kvVersion,omitempty"`
+
+	// Version is the kv version 2 secret version after the last sync
+	// +optional
+	Version int `json:"version,omitempty"`
+
+	// DataHash is the HMAC-SHA256 of the fields and their values keyed with the controller hash key
+	// +optional
+	DataHash string `json:"dataHash,omitempty"`
+
+	// LastSyncTime is the time of the last successful sync
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -96,6 +177,10 @@ type VaultMirrorVaultStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Bound\")].status",description=""
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Bound\")].message",description=""
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".status.address",description="",priority=1
+// +kubebuilder:printcolumn:name="Path",type="string",JSONPath=".status.path",description="",priority=1
+// +kubebuilder:printcolumn:name="Fields",type="string",JSONPath=".status.fields",description="",priority=1
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime",description="",priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
 
 // VaultMirror is the Schema for the vaultmirrors API
@@ -0,0 +1,123 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VaultSecretSpec defines the desired state of VaultSecret
+type VaultSecretSpec struct {
+	*VaultSpec `json:",inline"`
+
+	// Define the vault fields which must be mapped to the kubernetes secret.
+	// By default all fields of the vault path are mapped.
+	// +optional
+	Fields []FieldMapping `json:"fields,omitempty"`
+
+	// Vault does not provide a watch api, therefore the controller may read the path again in a specified interval
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Target defines the kubernetes secret which gets created
+	// +optional
+	Target VaultSecretTarget `json:"target,omitempty"`
+}
+
+// VaultSecretTarget defines the kubernetes secret created from a vault path
+type VaultSecretTarget struct {
+	// Name of the secret, by default the name of the VaultSecret is used
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Type of the secret, by default Opaque is used
+	// +optional
+	Type corev1.SecretType `json:"type,omitempty"`
+}
+
+// VaultSecretStatus defines the observed state of VaultSecret
+type VaultSecretStatus struct {
+	// Conditions holds the conditions for the VaultSecret.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncTime is the time the secret was last successfully read from vault and applied
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	ReconcileRequestStatus `json:",inline"`
+}
+
+// GetSecretName returns the name of the kubernetes secret
+func (in *VaultSecret) GetSecretName() string {
+	if in.Spec.Target.Name != "" {
+		return in.Spec.Target.Name
+	}
+
+	return in.GetName()
+}
+
+// VaultSecretNotBound de
+func VaultSecretNotBound(secret VaultSecret, reason, message string) VaultSecret {
+	setResourceCondition(&secret, BoundCondition, metav1.ConditionFalse, reason, message)
+	return secret
+}
+
+// VaultSecretBound de
+func VaultSecretBound(secret VaultSecret, reason, message string) VaultSecret {
+	setResourceCondition(&secret, BoundCondition, metav1.ConditionTrue, reason, message)
+	return secret
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *VaultSecret) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=vsec
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Bound\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Bound\")].message",description=""
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime",description="",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// VaultSecret is the Schema for the vaultsecrets API
+type VaultSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultSecretSpec   `json:"spec,omitempty"`
+	Status VaultSecretStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultSecretList contains a list of VaultSecret
+type VaultSecretList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultSecret `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultSecret{}, &VaultSecretList{})
+}
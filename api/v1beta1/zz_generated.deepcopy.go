@@ -27,6 +27,65 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVaultConnection) DeepCopyInto(out *ClusterVaultConnection) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVaultConnection.
+func (in *ClusterVaultConnection) DeepCopy() *ClusterVaultConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVaultConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterVaultConnection) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVaultConnectionList) DeepCopyInto(out *ClusterVaultConnectionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterVaultConnection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVaultConnectionList.
+func (in *ClusterVaultConnectionList) DeepCopy() *ClusterVaultConnectionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVaultConnectionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterVaultConnectionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FieldMapping) DeepCopyInto(out *FieldMapping) {
 	*out = *in
@@ -42,9 +101,55 @@ func (in *FieldMapping) DeepCopy() *FieldMapping {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcileRequestStatus) DeepCopyInto(out *ReconcileRequestStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcileRequestStatus.
+func (in *ReconcileRequestStatus) DeepCopy() *ReconcileRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcileRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAppRoleSpec) DeepCopyInto(out *VaultAppRoleSpec) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAppRoleSpec.
+func (in *VaultAppRoleSpec) DeepCopy() *VaultAppRoleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAppRoleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultAuthSpec) DeepCopyInto(out *VaultAuthSpec) {
 	*out = *in
+	if in.AppRole != nil {
+		in, out := &in.AppRole, &out.AppRole
+		*out = new(VaultAppRoleSpec)
+		**out = **in
+	}
+	if in.Token != nil {
+		in, out := &in.Token, &out.Token
+		*out = new(VaultTokenSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.JWT != nil {
+		in, out := &in.JWT, &out.JWT
+		*out = new(VaultJWTSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthSpec.
@@ -122,7 +227,7 @@ func (in *VaultBindingSpec) DeepCopyInto(out *VaultBindingSpec) {
 	if in.VaultSpec != nil {
 		in, out := &in.VaultSpec, &out.VaultSpec
 		*out = new(VaultSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Fields != nil {
 		in, out := &in.Fields, &out.Fields
@@ -134,6 +239,11 @@ func (in *VaultBindingSpec) DeepCopyInto(out *VaultBindingSpec) {
 		*out = new(v1.SecretReference)
 		**out = **in
 	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultBindingSpec.
@@ -156,7 +266,18 @@ func (in *VaultBindingStatus) DeepCopyInto(out *VaultBindingStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	out.Vault = in.Vault
+	if in.ManagedFields != nil {
+		in, out := &in.ManagedFields, &out.ManagedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(VaultTLSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	in.VaultBindingVaultStatus.DeepCopyInto(&out.VaultBindingVaultStatus)
+	out.ReconcileRequestStatus = in.ReconcileRequestStatus
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultBindingStatus.
@@ -172,6 +293,10 @@ func (in *VaultBindingStatus) DeepCopy() *VaultBindingStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultBindingVaultStatus) DeepCopyInto(out *VaultBindingVaultStatus) {
 	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultBindingVaultStatus.
@@ -184,6 +309,149 @@ func (in *VaultBindingVaultStatus) DeepCopy() *VaultBindingVaultStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultConnection) DeepCopyInto(out *VaultConnection) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConnection.
+func (in *VaultConnection) DeepCopy() *VaultConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultConnection) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultConnectionList) DeepCopyInto(out *VaultConnectionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VaultConnection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConnectionList.
+func (in *VaultConnectionList) DeepCopy() *VaultConnectionList {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultConnectionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultConnectionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultConnectionReference) DeepCopyInto(out *VaultConnectionReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConnectionReference.
+func (in *VaultConnectionReference) DeepCopy() *VaultConnectionReference {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultConnectionReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultConnectionSpec) DeepCopyInto(out *VaultConnectionSpec) {
+	*out = *in
+	in.TLSConfig.DeepCopyInto(&out.TLSConfig)
+	in.Auth.DeepCopyInto(&out.Auth)
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConnectionSpec.
+func (in *VaultConnectionSpec) DeepCopy() *VaultConnectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultConnectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultConnectionStatus) DeepCopyInto(out *VaultConnectionStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConnectionStatus.
+func (in *VaultConnectionStatus) DeepCopy() *VaultConnectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultConnectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultJWTSpec) DeepCopyInto(out *VaultJWTSpec) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExpirationSeconds != nil {
+		in, out := &in.ExpirationSeconds, &out.ExpirationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultJWTSpec.
+func (in *VaultJWTSpec) DeepCopy() *VaultJWTSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultJWTSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultMirror) DeepCopyInto(out *VaultMirror) {
 	*out = *in
@@ -249,12 +517,12 @@ func (in *VaultMirrorSpec) DeepCopyInto(out *VaultMirrorSpec) {
 	if in.Source != nil {
 		in, out := &in.Source, &out.Source
 		*out = new(VaultSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Destination != nil {
 		in, out := &in.Destination, &out.Destination
 		*out = new(VaultSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Interval != nil {
 		in, out := &in.Interval, &out.Interval
@@ -266,6 +534,16 @@ func (in *VaultMirrorSpec) DeepCopyInto(out *VaultMirrorSpec) {
 		*out = make([]FieldMapping, len(*in))
 		copy(*out, *in)
 	}
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultMirrorSpec.
@@ -288,7 +566,23 @@ func (in *VaultMirrorStatus) DeepCopyInto(out *VaultMirrorStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	out.Vault = in.Vault
+	if in.ManagedFields != nil {
+		in, out := &in.ManagedFields, &out.ManagedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SourceTLS != nil {
+		in, out := &in.SourceTLS, &out.SourceTLS
+		*out = new(VaultTLSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DestinationTLS != nil {
+		in, out := &in.DestinationTLS, &out.DestinationTLS
+		*out = new(VaultTLSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	in.VaultMirrorVaultStatus.DeepCopyInto(&out.VaultMirrorVaultStatus)
+	out.ReconcileRequestStatus = in.ReconcileRequestStatus
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultMirrorStatus.
@@ -304,6 +598,10 @@ func (in *VaultMirrorStatus) DeepCopy() *VaultMirrorStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultMirrorVaultStatus) DeepCopyInto(out *VaultMirrorVaultStatus) {
 	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultMirrorVaultStatus.
@@ -316,11 +614,148 @@ func (in *VaultMirrorVaultStatus) DeepCopy() *VaultMirrorVaultStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecret) DeepCopyInto(out *VaultSecret) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecret.
+func (in *VaultSecret) DeepCopy() *VaultSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultSecret) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretList) DeepCopyInto(out *VaultSecretList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VaultSecret, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretList.
+func (in *VaultSecretList) DeepCopy() *VaultSecretList {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultSecretList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretSpec) DeepCopyInto(out *VaultSecretSpec) {
+	*out = *in
+	if in.VaultSpec != nil {
+		in, out := &in.VaultSpec, &out.VaultSpec
+		*out = new(VaultSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]FieldMapping, len(*in))
+		copy(*out, *in)
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	out.Target = in.Target
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretSpec.
+func (in *VaultSecretSpec) DeepCopy() *VaultSecretSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretStatus) DeepCopyInto(out *VaultSecretStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	out.ReconcileRequestStatus = in.ReconcileRequestStatus
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretStatus.
+func (in *VaultSecretStatus) DeepCopy() *VaultSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretTarget) DeepCopyInto(out *VaultSecretTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretTarget.
+func (in *VaultSecretTarget) DeepCopy() *VaultSecretTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultSpec) DeepCopyInto(out *VaultSpec) {
 	*out = *in
-	out.TLSConfig = in.TLSConfig
-	out.Auth = in.Auth
+	if in.ConnectionRef != nil {
+		in, out := &in.ConnectionRef, &out.ConnectionRef
+		*out = new(VaultConnectionReference)
+		**out = **in
+	}
+	in.TLSConfig.DeepCopyInto(&out.TLSConfig)
+	in.Auth.DeepCopyInto(&out.Auth)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSpec.
@@ -336,6 +771,26 @@ func (in *VaultSpec) DeepCopy() *VaultSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultTLSSpec) DeepCopyInto(out *VaultTLSSpec) {
 	*out = *in
+	if in.CACertSecretRef != nil {
+		in, out := &in.CACertSecretRef, &out.CACertSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CACertConfigMapRef != nil {
+		in, out := &in.CACertConfigMapRef, &out.CACertConfigMapRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientCertSecretRef != nil {
+		in, out := &in.ClientCertSecretRef, &out.ClientCertSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientKeySecretRef != nil {
+		in, out := &in.ClientKeySecretRef, &out.ClientKeySecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultTLSSpec.
@@ -347,3 +802,46 @@ func (in *VaultTLSSpec) DeepCopy() *VaultTLSSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultTLSStatus) DeepCopyInto(out *VaultTLSStatus) {
+	*out = *in
+	if in.CACertExpiry != nil {
+		in, out := &in.CACertExpiry, &out.CACertExpiry
+		*out = (*in).DeepCopy()
+	}
+	if in.ClientCertExpiry != nil {
+		in, out := &in.ClientCertExpiry, &out.ClientCertExpiry
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultTLSStatus.
+func (in *VaultTLSStatus) DeepCopy() *VaultTLSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultTLSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultTokenSpec) DeepCopyInto(out *VaultTokenSpec) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultTokenSpec.
+func (in *VaultTokenSpec) DeepCopy() *VaultTokenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultTokenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,97 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DoodleScheduling/k8svault-controller/internal/audit"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+// lastManager returns the field manager which made the latest change to one of the objects.
+// Changes to status subresources are ignored as they are made by the controller.
+// It is only the most recent field manager, not necessarily the identity which caused a mutation.
+func lastManager(objs ...metav1.Object) string {
+	var manager string
+	var latest time.Time
+	for _, obj := range objs {
+		for _, entry := range obj.GetManagedFields() {
+			if entry.Subresource != "" || entry.Time == nil {
+				continue
+			}
+
+			if manager == "" || entry.Time.After(latest) {
+				manager = entry.Manager
+				latest = entry.Time.Time
+			}
+		}
+	}
+
+	return manager
+}
+
+// writeRecord describes a write to vault caused by a resource
+// Values are only recorded as the keyed hashes of the write result, never in plain or as a plain hash.
+func writeRecord(kind string, obj metav1.Object, actor, address string, result vault.WriteResult) audit.Record {
+	return audit.Record{
+		Operation:    audit.OperationWrite,
+		Kind:         kind,
+		Namespace:    obj.GetNamespace(),
+		Name:         obj.GetName(),
+		Actor:        actor,
+		Address:      address,
+		Path:         result.Path,
+		Version:      result.Version,
+		Added:        result.Added,
+		Updated:      result.Updated,
+		Skipped:      result.Skipped,
+		PreviousHash: result.PreviousHash,
+		Hash:         result.DataHash,
+	}
+}
+
+// removeRecord describes fields removed from vault by a resource
+func removeRecord(operation, kind string, obj metav1.Object, actor, address, path string, removed []string) audit.Record {
+	return audit.Record{
+		Operation: operation,
+		Kind:      kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Actor:     actor,
+		Address:   address,
+		Path:      path,
+		Removed:   removed,
+	}
+}
+
+// emitAudit passes a record to the audit sink if there is one.
+// Failures are only logged as vault was already changed.
+func emitAudit(ctx context.Context, sink audit.Sink, record audit.Record, logger logr.Logger) {
+	if sink == nil {
+		return
+	}
+
+	record.Time = time.Now().UTC()
+	if err := sink.Emit(ctx, record); err != nil {
+		logger.Error(err, "failed to emit audit record", "path", record.Path)
+	}
+}
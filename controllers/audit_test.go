@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/audit"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+func managedFieldsEntry(manager, subresource string, t time.Time) metav1.ManagedFieldsEntry {
+	mt := metav1.NewTime(t)
+	return metav1.ManagedFieldsEntry{
+		Manager:     manager,
+		Operation:   metav1.ManagedFieldsOperationUpdate,
+		Subresource: subresource,
+		Time:        &mt,
+	}
+}
+
+func TestLastManager(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	binding := &v1beta1.VaultBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				managedFieldsEntry("kubectl-client-side-apply", "", now.Add(-time.Hour)),
+				managedFieldsEntry("k8svault-controller", "status", now),
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				managedFieldsEntry("argocd-controller", "", now.Add(-time.Minute)),
+			},
+		},
+	}
+
+	g.Expect(lastManager()).To(Equal(""))
+	g.Expect(lastManager(binding)).To(Equal("kubectl-client-side-apply"))
+	g.Expect(lastManager(binding, secret)).To(Equal("argocd-controller"))
+}
+
+type recordingSink struct {
+	records []audit.Record
+}
+
+func (s *recordingSink) Emit(ctx context.Context, record audit.Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestEmitAudit(t *testing.T) {
+	g := NewWithT(t)
+
+	binding := &v1beta1.VaultBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "app",
+		},
+	}
+
+	// No sink configured
+	emitAudit(context.Background(), nil, removeRecord(audit.OperationPrune, "VaultBinding", binding, "", "", "", nil), logr.Discard())
+
+	sink := &recordingSink{}
+	emitAudit(context.Background(), sink, writeRecord("VaultBinding", binding, "kubectl", "https://vault:8200", vault.WriteResult{
+		Path:         "secret/data/app",
+		Version:      3,
+		Added:        []string{"password"},
+		Updated:      []string{"username"},
+		Skipped:      []string{"token"},
+		PreviousHash: "old",
+		DataHash:     "new",
+	}), logr.Discard())
+
+	g.Expect(sink.records).To(HaveLen(1))
+	record := sink.records[0]
+	g.Expect(record.Time).NotTo(BeZero())
+	record.Time = time.Time{}
+	g.Expect(record).To(Equal(audit.Record{
+		Operation:    audit.OperationWrite,
+		Kind:         "VaultBinding",
+		Namespace:    "default",
+		Name:         "app",
+		Actor:        "kubectl",
+		Address:      "https://vault:8200",
+		Path:         "secret/data/app",
+		Version:      3,
+		Added:        []string{"password"},
+		Updated:      []string{"username"},
+		Skipped:      []string{"token"},
+		PreviousHash: "old",
+		Hash:         "new",
+	}))
+}
+
+func TestWriteRecordHash(t *testing.T) {
+	g := NewWithT(t)
+
+	data := map[string]interface{}{"password": "secret"}
+	hash, err := vault.DataHash(data, []string{"password"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	record := writeRecord("VaultBinding", &v1beta1.VaultBinding{}, "", "", vault.WriteResult{
+		Fields:   []string{"password"},
+		DataHash: hash,
+	})
+
+	g.Expect(record.Hash).To(Equal(hash))
+	g.Expect(record.Hash).NotTo(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte(`{"password":"secret"}`)))))
+}
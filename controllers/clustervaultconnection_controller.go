@@ -0,0 +1,72 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+// +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=clustervaultconnections,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=clustervaultconnections/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// ClusterVaultConnection reconciles a ClusterVaultConnection object
+type ClusterVaultConnectionReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// ControllerNamespace is the namespace the references of the connections are resolved in
+	ControllerNamespace string
+}
+
+type ClusterVaultConnectionReconcilerOptions struct {
+	MaxConcurrentReconciles int
+}
+
+// SetupWithManager adding controllers
+func (r *ClusterVaultConnectionReconciler) SetupWithManager(mgr ctrl.Manager, opts ClusterVaultConnectionReconcilerOptions) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.ClusterVaultConnection{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		WithOptions(controller.Options{MaxConcurrentReconciles: opts.MaxConcurrentReconciles}).
+		Complete(r)
+}
+
+// Reconcile ClusterVaultConnections
+func (r *ClusterVaultConnectionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Log.WithValues("Name", req.Name)
+
+	// References of a cluster connection are resolved in the controller namespace
+	c := &connectionReconciler{Client: r.Client, Recorder: r.Recorder}
+	return c.reconcileConnection(ctx, req, v1beta1.ClusterVaultConnectionKind, &v1beta1.ClusterVaultConnection{}, vault.HandlerOptions{
+		Client:    r.Client,
+		Namespace: r.ControllerNamespace,
+	}, logger)
+}
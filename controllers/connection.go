@@ -0,0 +1,217 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/tracing"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+const (
+	// connectionIndexKey is the key used for indexing resources based on
+	// the vault connections they reference.
+	connectionIndexKey string = ".spec.connectionRef"
+)
+
+// connectionRefKey returns the index key of a connection referenced from a resource in the given namespace
+func connectionRefKey(namespace string, ref *v1beta1.VaultConnectionReference) string {
+	if ref.Kind == v1beta1.ClusterVaultConnectionKind {
+		return fmt.Sprintf("%s/%s", v1beta1.ClusterVaultConnectionKind, ref.Name)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", v1beta1.VaultConnectionKind, namespace, ref.Name)
+}
+
+// connectionRefs returns the index keys of all connections referenced by the vault specs
+func connectionRefs(namespace string, specs ...*v1beta1.VaultSpec) []string {
+	var keys []string
+	for _, spec := range specs {
+		if spec != nil && spec.ConnectionRef != nil {
+			keys = append(keys, connectionRefKey(namespace, spec.ConnectionRef))
+		}
+	}
+
+	return keys
+}
+
+// watchConnections indexes the resources by the connections they reference
+// and reconciles them if a referenced connection changes
+func watchConnections(mgr ctrl.Manager, b *builder.Builder, obj client.Object, list client.ObjectList, refs func(client.Object) []string, log logr.Logger) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), obj, connectionIndexKey, refs); err != nil {
+		return err
+	}
+
+	// Status updates of the health checks do not change the connection settings
+	mapFunc := requestsForConnectionChange(mgr.GetClient(), list, log)
+	b.Watches(&source.Kind{Type: &v1beta1.VaultConnection{}}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &v1beta1.ClusterVaultConnection{}}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+
+	return nil
+}
+
+func requestsForConnectionChange(c client.Reader, list client.ObjectList, log logr.Logger) handler.MapFunc {
+	return func(o client.Object) []reconcile.Request {
+		var key string
+		switch conn := o.(type) {
+		case *v1beta1.VaultConnection:
+			key = connectionRefKey(conn.GetNamespace(), &v1beta1.VaultConnectionReference{Name: conn.GetName()})
+		case *v1beta1.ClusterVaultConnection:
+			key = connectionRefKey("", &v1beta1.VaultConnectionReference{Kind: v1beta1.ClusterVaultConnectionKind, Name: conn.GetName()})
+		default:
+			panic(fmt.Sprintf("expected a vault connection, got %T", o))
+		}
+
+		l := list.DeepCopyObject().(client.ObjectList)
+		if err := c.List(context.Background(), l, client.MatchingFields{
+			connectionIndexKey: key,
+		}); err != nil {
+			return nil
+		}
+
+		items, err := apimeta.ExtractList(l)
+		if err != nil {
+			return nil
+		}
+
+		var reqs []reconcile.Request
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok {
+				continue
+			}
+
+			log.Info("referenced vault connection changed, reconcile resource", "connection", key, "namespace", obj.GetNamespace(), "name", obj.GetName())
+			reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(obj)})
+		}
+
+		return reqs
+	}
+}
+
+// connectionFailedReason returns the condition reason if a connection to vault could not be setup
+func connectionFailedReason(err error) string {
+	if errors.Is(err, vault.ErrTLSConfigInvalid) {
+		return v1beta1.TLSConfigInvalidReason
+	}
+
+	return v1beta1.VaultConnectionFailedReason
+}
+
+// vaultConnection is a VaultConnection or ClusterVaultConnection
+type vaultConnection interface {
+	client.Object
+	GetStatusConditions() *[]metav1.Condition
+	GetConnectionSpec() *v1beta1.VaultConnectionSpec
+	GetConnectionStatus() *v1beta1.VaultConnectionStatus
+}
+
+// connectionReconciler checks the health of the vault servers of VaultConnections and ClusterVaultConnections
+type connectionReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+}
+
+// reconcileConnection fetches the connection of the request into conn, checks the health of its vault server
+// and updates its status. References of the connection are resolved in the namespace of the options.
+func (r *connectionReconciler) reconcileConnection(ctx context.Context, req ctrl.Request, kind string, conn vaultConnection, opts vault.HandlerOptions, logger logr.Logger) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, kind+".Reconcile", tracing.ResourceAttributes(kind, req.Namespace, req.Name)...)
+	defer span.End()
+
+	logger.Info("reconciling " + kind)
+
+	err := r.Client.Get(ctx, req.NamespacedName, conn)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Return and don't requeue
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	result, reconcileErr := r.checkHealth(ctx, conn, opts)
+	conn.GetConnectionStatus().ObservedGeneration = conn.GetGeneration()
+
+	// Update status after reconciliation.
+	if err = r.patchStatus(ctx, conn); err != nil {
+		logger.Error(err, "unable to update status after reconciliation")
+		return ctrl.Result{Requeue: true}, err
+	}
+
+	tracing.RecordError(span, reconcileErr)
+	return result, reconcileErr
+}
+
+func (r *connectionReconciler) checkHealth(ctx context.Context, conn vaultConnection, opts vault.HandlerOptions) (ctrl.Result, error) {
+	spec := conn.GetConnectionSpec()
+	health, err := vault.CheckHealth(ctx, spec, opts)
+
+	// Reqeue only if an interval is specified
+	result := ctrl.Result{}
+	if spec.Interval != nil {
+		result = ctrl.Result{RequeueAfter: spec.Interval.Duration}
+	}
+
+	if health != nil {
+		conn.GetConnectionStatus().Version = health.Version
+	}
+
+	if err == vault.ErrVaultSealed {
+		msg := err.Error()
+		r.Recorder.Event(conn, "Normal", "error", msg)
+		v1beta1.ConnectionNotReady(conn, v1beta1.VaultSealedReason, msg)
+		return result, nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("Connection to vault failed: %s", err.Error())
+		r.Recorder.Event(conn, "Normal", "error", msg)
+		v1beta1.ConnectionNotReady(conn, connectionFailedReason(err), msg)
+		return ctrl.Result{Requeue: true}, err
+	}
+
+	v1beta1.ConnectionReady(conn, v1beta1.VaultHealthyReason, "Vault is healthy")
+	return result, nil
+}
+
+func (r *connectionReconciler) patchStatus(ctx context.Context, conn vaultConnection) error {
+	latest := conn.DeepCopyObject().(client.Object)
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(conn), latest); err != nil {
+		return err
+	}
+
+	return r.Client.Status().Patch(ctx, conn, client.MergeFrom(latest))
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+func TestConnectionRefs(t *testing.T) {
+	g := NewWithT(t)
+
+	refs := connectionRefs("default",
+		&v1beta1.VaultSpec{
+			ConnectionRef: &v1beta1.VaultConnectionReference{Name: "source"},
+		},
+		&v1beta1.VaultSpec{
+			Address: "https://vault:8200",
+		},
+		&v1beta1.VaultSpec{
+			ConnectionRef: &v1beta1.VaultConnectionReference{Kind: v1beta1.ClusterVaultConnectionKind, Name: "destination"},
+		},
+		nil,
+	)
+
+	g.Expect(refs).To(Equal([]string{
+		"VaultConnection/default/source",
+		"ClusterVaultConnection/destination",
+	}))
+}
+
+func TestRequestsForConnectionChange(t *testing.T) {
+	g := NewWithT(t)
+
+	reader := &testPathReader{
+		bindings: []v1beta1.VaultBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "binding", Namespace: "default"},
+			},
+		},
+	}
+
+	mapFunc := requestsForConnectionChange(reader, &v1beta1.VaultBindingList{}, logr.Discard())
+	reqs := mapFunc(&v1beta1.ClusterVaultConnection{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault"},
+	})
+
+	g.Expect(reqs).To(Equal([]reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "default", Name: "binding"}},
+	}))
+}
+
+func TestConnectionFailedReason(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(connectionFailedReason(fmt.Errorf("%w: ca certificate from file /ca.crt", vault.ErrTLSConfigInvalid))).To(Equal(v1beta1.TLSConfigInvalidReason))
+	g.Expect(connectionFailedReason(errors.New("connection refused"))).To(Equal(v1beta1.VaultConnectionFailedReason))
+}
+
+func TestCheckHealth(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name          string
+		health        string
+		expectStatus  metav1.ConditionStatus
+		expectReason  string
+		expectVersion string
+	}{
+		{
+			name:          "healthy vault",
+			health:        `{"initialized":true,"sealed":false,"version":"1.13.0"}`,
+			expectStatus:  metav1.ConditionTrue,
+			expectReason:  v1beta1.VaultHealthyReason,
+			expectVersion: "1.13.0",
+		},
+		{
+			name:          "sealed vault",
+			health:        `{"initialized":true,"sealed":true,"version":"1.13.0"}`,
+			expectStatus:  metav1.ConditionFalse,
+			expectReason:  v1beta1.VaultSealedReason,
+			expectVersion: "1.13.0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(test.health))
+			}))
+			defer server.Close()
+
+			conn := &v1beta1.ClusterVaultConnection{
+				ObjectMeta: metav1.ObjectMeta{Name: "vault"},
+				Spec: v1beta1.VaultConnectionSpec{
+					Address:  server.URL,
+					Interval: &metav1.Duration{Duration: time.Minute},
+				},
+			}
+
+			r := &connectionReconciler{Recorder: record.NewFakeRecorder(10)}
+			result, err := r.checkHealth(context.TODO(), conn, vault.HandlerOptions{})
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+			g.Expect(conn.Status.Version).To(Equal(test.expectVersion))
+			g.Expect(conn.Status.Conditions).To(HaveLen(1))
+			g.Expect(conn.Status.Conditions[0].Status).To(Equal(test.expectStatus))
+			g.Expect(conn.Status.Conditions[0].Reason).To(Equal(test.expectReason))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sort"
+	"strings"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+// mappedData returns the source values by their destination field name
+// Without a field mapping all source fields are mapped.
+func mappedData(mapping []v1beta1.FieldMapping, srcData map[string]interface{}) map[string]interface{} {
+	if len(mapping) == 0 {
+		return srcData
+	}
+
+	data := make(map[string]interface{}, len(mapping))
+	for _, field := range mapping {
+		value, ok := srcData[field.Name]
+		if !ok {
+			continue
+		}
+
+		if field.Rename != "" {
+			data[field.Rename] = value
+		} else {
+			data[field.Name] = value
+		}
+	}
+
+	return data
+}
+
+// driftedFields returns the fields of the last sync which were changed or deleted in vault since.
+// A drift is detected by comparing the data hash of the last sync with the current vault data,
+// the drifted fields are the ones differing from the desired value. Fields owned by other resources are ignored.
+func driftedFields(last v1beta1.VaultBindingVaultStatus, desired, vaultData map[string]interface{}, conflicts map[string]fieldOwner) ([]string, error) {
+	if last.DataHash == "" || last.Fields == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(last.Fields, ",")
+	hash, err := vault.DataHash(vaultData, fields)
+	if err != nil || hash == last.DataHash {
+		return nil, err
+	}
+
+	var drifted []string
+	for _, field := range fields {
+		if _, ok := conflicts[field]; ok {
+			continue
+		}
+
+		value, ok := desired[field]
+		if !ok {
+			continue
+		}
+
+		if current, ok := vaultData[field]; !ok || current != value {
+			drifted = append(drifted, field)
+		}
+	}
+
+	sort.Strings(drifted)
+	return drifted, nil
+}
+
+// syncedFields returns the fields covered by drift detection after a sync, these are the fields holding the
+// mapped value and the drifted fields which were not re-applied so their drift is reported until it is resolved.
+// They are tracked independently of the managed fields, fields which are not owned by the binding are compared as well.
+func syncedFields(result vault.WriteResult, drifted []string) []string {
+	synced := append([]string{}, result.Fields...)
+	for _, field := range drifted {
+		if !contains(synced, field) {
+			synced = append(synced, field)
+		}
+	}
+
+	sort.Strings(synced)
+	return synced
+}
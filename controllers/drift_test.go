@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+func TestMappedData(t *testing.T) {
+	g := NewWithT(t)
+
+	srcData := map[string]interface{}{
+		"fruit":     "banana",
+		"vegetable": "carrot",
+	}
+
+	g.Expect(mappedData(nil, srcData)).To(Equal(srcData))
+	g.Expect(mappedData([]v1beta1.FieldMapping{
+		{Name: "fruit", Rename: "berry"},
+		{Name: "vegetable"},
+		{Name: "does-not-exist"},
+	}, srcData)).To(Equal(map[string]interface{}{
+		"berry":     "banana",
+		"vegetable": "carrot",
+	}))
+}
+
+func TestDriftedFields(t *testing.T) {
+	g := NewWithT(t)
+
+	synced := map[string]interface{}{
+		"fruit":     "banana",
+		"vegetable": "carrot",
+	}
+
+	hash, err := vault.DataHash(synced, []string{"fruit", "vegetable"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	last := v1beta1.VaultBindingVaultStatus{
+		Fields:   "fruit,vegetable",
+		DataHash: hash,
+	}
+
+	tests := []struct {
+		name         string
+		last         v1beta1.VaultBindingVaultStatus
+		desired      map[string]interface{}
+		vaultData    map[string]interface{}
+		conflicts    map[string]fieldOwner
+		expectFields []string
+	}{
+		{
+			name:      "no drift without a previous sync",
+			desired:   synced,
+			vaultData: map[string]interface{}{},
+		},
+		{
+			name:      "no drift if vault is unchanged",
+			last:      last,
+			desired:   synced,
+			vaultData: map[string]interface{}{"fruit": "banana", "vegetable": "carrot", "nut": "peanut"},
+		},
+		{
+			name:      "no drift if only the source changed",
+			last:      last,
+			desired:   map[string]interface{}{"fruit": "apple", "vegetable": "carrot"},
+			vaultData: synced,
+		},
+		{
+			name:         "changed and deleted fields drifted",
+			last:         last,
+			desired:      synced,
+			vaultData:    map[string]interface{}{"fruit": "apple"},
+			expectFields: []string{"fruit", "vegetable"},
+		},
+		{
+			name:      "fields owned by other resources and not mapped anymore are ignored",
+			last:      last,
+			desired:   map[string]interface{}{"vegetable": "carrot"},
+			vaultData: map[string]interface{}{"vegetable": "potato"},
+			conflicts: map[string]fieldOwner{
+				"vegetable": {},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields, err := driftedFields(test.last, test.desired, test.vaultData, test.conflicts)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(fields).To(Equal(test.expectFields))
+		})
+	}
+}
+
+func TestSyncedFields(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name         string
+		result       vault.WriteResult
+		drifted      []string
+		expectSynced []string
+	}{
+		{
+			name: "fields which already held the value are synced",
+			result: vault.WriteResult{
+				Fields: []string{"vegetable", "fruit"},
+			},
+			expectSynced: []string{"fruit", "vegetable"},
+		},
+		{
+			name: "drifted fields which were not re-applied are synced",
+			result: vault.WriteResult{
+				Fields:  []string{"fruit"},
+				Skipped: []string{"vegetable"},
+			},
+			drifted:      []string{"vegetable"},
+			expectSynced: []string{"fruit", "vegetable"},
+		},
+		{
+			name: "skipped fields which did not drift are not synced",
+			result: vault.WriteResult{
+				Fields:  []string{"fruit"},
+				Skipped: []string{"vegetable"},
+			},
+			expectSynced: []string{"fruit"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g.Expect(syncedFields(test.result, test.drifted)).To(Equal(test.expectSynced))
+		})
+	}
+}
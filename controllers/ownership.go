@@ -0,0 +1,265 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+const (
+	// vaultPathIndexKey is the key used for indexing VaultBindings and VaultMirrors
+	// based on the vault path they write to.
+	vaultPathIndexKey string = ".spec.vaultPath"
+)
+
+// vaultPathKey identifies a vault path across vault servers and namespaces
+// Paths of resources using a connection are identified by the connection.
+func vaultPathKey(namespace string, spec *v1beta1.VaultSpec) string {
+	if spec == nil {
+		return ""
+	}
+
+	if spec.ConnectionRef != nil {
+		return fmt.Sprintf("%s|%s", connectionRefKey(namespace, spec.ConnectionRef), strings.Trim(spec.Path, "/"))
+	}
+
+	return fmt.Sprintf("%s|%s|%s", spec.Address, spec.Namespace, strings.Trim(spec.Path, "/"))
+}
+
+// fieldOwner is a resource managing fields of a vault path
+type fieldOwner struct {
+	kind    string
+	object  client.Object
+	managed []string
+}
+
+func (o fieldOwner) String() string {
+	return fmt.Sprintf("%s %s/%s", o.kind, o.object.GetNamespace(), o.object.GetName())
+}
+
+// owns returns true if the owner takes precedence over the other resource for a field both manage
+// The resource created first owns the field.
+func (o fieldOwner) owns(other client.Object) bool {
+	a, b := o.object.GetCreationTimestamp(), other.GetCreationTimestamp()
+	if !a.Equal(&b) {
+		return a.Before(&b)
+	}
+
+	return client.ObjectKeyFromObject(o.object).String() < client.ObjectKeyFromObject(other).String()
+}
+
+// fieldConflicts returns the desired fields which are owned by other resources writing to the same vault path
+// mapped to the owner. A field is owned by the resource which manages it, if multiple resources manage
+// the same field the one created first owns it.
+func fieldConflicts(ctx context.Context, c client.Reader, self client.Object, managed []string, spec *v1beta1.VaultSpec, desired []string) (map[string]fieldOwner, error) {
+	owners, err := pathOwners(ctx, c, vaultPathKey(self.GetNamespace(), spec))
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make(map[string]fieldOwner)
+	for _, owner := range owners {
+		if owner.object.GetUID() == self.GetUID() {
+			continue
+		}
+
+		for _, field := range desired {
+			if !contains(owner.managed, field) {
+				continue
+			}
+
+			if contains(managed, field) && !owner.owns(self) {
+				continue
+			}
+
+			conflicts[field] = owner
+		}
+	}
+
+	return conflicts, nil
+}
+
+// unclaimedFields returns the fields which are not managed by any other resource writing to the same vault path.
+// Fields a resource stopped mapping may have been claimed by another resource since, these must not be removed.
+func unclaimedFields(ctx context.Context, c client.Reader, self client.Object, spec *v1beta1.VaultSpec, fields []string) ([]string, error) {
+	claimed, err := fieldConflicts(ctx, c, self, nil, spec, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var unclaimed []string
+	for _, field := range fields {
+		if _, ok := claimed[field]; !ok {
+			unclaimed = append(unclaimed, field)
+		}
+	}
+
+	return unclaimed, nil
+}
+
+// pathOwners returns all resources which write to the vault path identified by the key
+func pathOwners(ctx context.Context, c client.Reader, pathKey string) ([]fieldOwner, error) {
+	var owners []fieldOwner
+	key := client.MatchingFields{
+		vaultPathIndexKey: pathKey,
+	}
+
+	var bindings v1beta1.VaultBindingList
+	if err := c.List(ctx, &bindings, key); err != nil {
+		return nil, err
+	}
+
+	for i := range bindings.Items {
+		owners = append(owners, fieldOwner{
+			kind:    "VaultBinding",
+			object:  &bindings.Items[i],
+			managed: bindings.Items[i].Status.ManagedFields,
+		})
+	}
+
+	var mirrors v1beta1.VaultMirrorList
+	if err := c.List(ctx, &mirrors, key); err != nil {
+		return nil, err
+	}
+
+	for i := range mirrors.Items {
+		owners = append(owners, fieldOwner{
+			kind:    "VaultMirror",
+			object:  &mirrors.Items[i],
+			managed: mirrors.Items[i].Status.ManagedFields,
+		})
+	}
+
+	return owners, nil
+}
+
+// conflictMessage describes the conflicting fields and their owners
+func conflictMessage(conflicts map[string]fieldOwner) string {
+	var fields []string
+	for field, owner := range conflicts {
+		fields = append(fields, fmt.Sprintf("%s (%s)", field, owner))
+	}
+
+	sort.Strings(fields)
+	return fmt.Sprintf("Fields owned by other resources are not written: %s", strings.Join(fields, ", "))
+}
+
+// mappedFields returns the destination fields of a field mapping which are present in the source data
+// Without a field mapping all source fields are mapped.
+func mappedFields(mapping []v1beta1.FieldMapping, srcData map[string]interface{}) []string {
+	var fields []string
+	for _, field := range mapping {
+		if _, ok := srcData[field.Name]; !ok {
+			continue
+		}
+
+		if field.Rename != "" {
+			fields = append(fields, field.Rename)
+		} else {
+			fields = append(fields, field.Name)
+		}
+	}
+
+	if len(mapping) == 0 {
+		for k := range srcData {
+			fields = append(fields, k)
+		}
+	}
+
+	sort.Strings(fields)
+	return fields
+}
+
+// excludeMapper maps the fields of the wrapped mapper except excluded ones
+type excludeMapper struct {
+	vault.Mapper
+	mapping []v1beta1.FieldMapping
+}
+
+func (m *excludeMapper) GetFieldMapping() []v1beta1.FieldMapping {
+	return m.mapping
+}
+
+// withoutFields returns a mapper which does not map the given destination fields
+// It returns false if no fields are left to be mapped.
+func withoutFields(m vault.Mapper, srcData map[string]interface{}, exclude map[string]fieldOwner) (vault.Mapper, bool) {
+	if len(exclude) == 0 {
+		return m, true
+	}
+
+	mapping := m.GetFieldMapping()
+	if len(mapping) == 0 {
+		for k := range srcData {
+			mapping = append(mapping, v1beta1.FieldMapping{
+				Name: k,
+			})
+		}
+	}
+
+	var filtered []v1beta1.FieldMapping
+	for _, field := range mapping {
+		dstField := field.Name
+		if field.Rename != "" {
+			dstField = field.Rename
+		}
+
+		if _, ok := exclude[dstField]; !ok {
+			filtered = append(filtered, field)
+		}
+	}
+
+	return &excludeMapper{Mapper: m, mapping: filtered}, len(filtered) > 0
+}
+
+// ownedFields returns the fields a resource manages after a write, these are the fields which hold its value.
+// Fields which already held the desired value are adopted, fields which were skipped because they hold
+// a different value are not claimed.
+func ownedFields(result vault.WriteResult) []string {
+	owned := append([]string{}, result.Fields...)
+	sort.Strings(owned)
+	return owned
+}
+
+// staleFields returns the previously managed fields which are not desired anymore
+func staleFields(managed, desired []string) []string {
+	var stale []string
+	for _, field := range managed {
+		if !contains(desired, field) {
+			stale = append(stale, field)
+		}
+	}
+
+	return stale
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
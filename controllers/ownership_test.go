@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+// testPathReader returns the given resources for every list request
+type testPathReader struct {
+	client.Reader
+	bindings []v1beta1.VaultBinding
+	mirrors  []v1beta1.VaultMirror
+}
+
+func (r *testPathReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	switch l := list.(type) {
+	case *v1beta1.VaultBindingList:
+		l.Items = r.bindings
+	case *v1beta1.VaultMirrorList:
+		l.Items = r.mirrors
+	}
+
+	return nil
+}
+
+func testBinding(name string, created time.Time, managed ...string) v1beta1.VaultBinding {
+	return v1beta1.VaultBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			UID:               types.UID(name),
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: v1beta1.VaultBindingStatus{
+			ManagedFields: managed,
+		},
+	}
+}
+
+func TestFieldConflicts(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+
+	tests := []struct {
+		name            string
+		self            v1beta1.VaultBinding
+		bindings        []v1beta1.VaultBinding
+		mirrors         []v1beta1.VaultMirror
+		desired         []string
+		expectConflicts []string
+	}{
+		{
+			name:     "no conflict if fields are not managed by others",
+			self:     testBinding("self", now),
+			bindings: []v1beta1.VaultBinding{testBinding("other", now, "vegetable")},
+			desired:  []string{"fruit"},
+		},
+		{
+			name:            "conflict if field is managed by another binding",
+			self:            testBinding("self", now),
+			bindings:        []v1beta1.VaultBinding{testBinding("other", now.Add(time.Hour), "fruit")},
+			desired:         []string{"fruit", "vegetable"},
+			expectConflicts: []string{"fruit"},
+		},
+		{
+			name: "conflict if field is managed by a mirror",
+			self: testBinding("self", now),
+			mirrors: []v1beta1.VaultMirror{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "mirror", UID: "mirror"},
+					Status: v1beta1.VaultMirrorStatus{
+						ManagedFields: []string{"fruit"},
+					},
+				},
+			},
+			desired:         []string{"fruit"},
+			expectConflicts: []string{"fruit"},
+		},
+		{
+			name:     "ignore own managed fields",
+			self:     testBinding("self", now, "fruit"),
+			bindings: []v1beta1.VaultBinding{testBinding("self", now, "fruit")},
+			desired:  []string{"fruit"},
+		},
+		{
+			name:     "the older resource owns a field managed by both",
+			self:     testBinding("self", now, "fruit"),
+			bindings: []v1beta1.VaultBinding{testBinding("other", now.Add(time.Hour), "fruit")},
+			desired:  []string{"fruit"},
+		},
+		{
+			name:            "the newer resource loses a field managed by both",
+			self:            testBinding("self", now.Add(time.Hour), "fruit"),
+			bindings:        []v1beta1.VaultBinding{testBinding("other", now, "fruit")},
+			desired:         []string{"fruit"},
+			expectConflicts: []string{"fruit"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := &testPathReader{
+				bindings: test.bindings,
+				mirrors:  test.mirrors,
+			}
+
+			conflicts, err := fieldConflicts(context.TODO(), reader, &test.self, test.self.Status.ManagedFields, &v1beta1.VaultSpec{Path: "/food"}, test.desired)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			var fields []string
+			for field := range conflicts {
+				fields = append(fields, field)
+			}
+
+			g.Expect(fields).To(Equal(test.expectConflicts))
+		})
+	}
+}
+
+func TestUnclaimedFields(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+
+	tests := []struct {
+		name            string
+		self            v1beta1.VaultBinding
+		bindings        []v1beta1.VaultBinding
+		fields          []string
+		expectUnclaimed []string
+	}{
+		{
+			name:            "keep fields which are not managed by others",
+			self:            testBinding("self", now, "fruit"),
+			bindings:        []v1beta1.VaultBinding{testBinding("other", now, "vegetable")},
+			fields:          []string{"fruit"},
+			expectUnclaimed: []string{"fruit"},
+		},
+		{
+			name:            "skip fields claimed by a newer binding",
+			self:            testBinding("self", now, "fruit", "vegetable"),
+			bindings:        []v1beta1.VaultBinding{testBinding("other", now.Add(time.Hour), "fruit")},
+			fields:          []string{"fruit", "vegetable"},
+			expectUnclaimed: []string{"vegetable"},
+		},
+		{
+			name:     "skip fields claimed by an older binding",
+			self:     testBinding("self", now.Add(time.Hour), "fruit"),
+			bindings: []v1beta1.VaultBinding{testBinding("other", now, "fruit")},
+			fields:   []string{"fruit"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := &testPathReader{
+				bindings: append(test.bindings, test.self),
+			}
+
+			unclaimed, err := unclaimedFields(context.TODO(), reader, &test.self, &v1beta1.VaultSpec{Path: "/food"}, test.fields)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(unclaimed).To(Equal(test.expectUnclaimed))
+		})
+	}
+}
+
+func TestWithoutFields(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name          string
+		mapping       []v1beta1.FieldMapping
+		exclude       []string
+		expectMapping []v1beta1.FieldMapping
+		expectOK      bool
+	}{
+		{
+			name: "keep mapping without exclusions",
+			mapping: []v1beta1.FieldMapping{
+				{Name: "fruit"},
+			},
+			expectMapping: []v1beta1.FieldMapping{
+				{Name: "fruit"},
+			},
+			expectOK: true,
+		},
+		{
+			name: "exclude renamed destination field",
+			mapping: []v1beta1.FieldMapping{
+				{Name: "fruit", Rename: "berry"},
+				{Name: "vegetable"},
+			},
+			exclude: []string{"berry"},
+			expectMapping: []v1beta1.FieldMapping{
+				{Name: "vegetable"},
+			},
+			expectOK: true,
+		},
+		{
+			name:    "exclude source fields if no mapping is configured",
+			exclude: []string{"fruit"},
+			expectMapping: []v1beta1.FieldMapping{
+				{Name: "vegetable"},
+			},
+			expectOK: true,
+		},
+		{
+			name: "nothing left to map",
+			mapping: []v1beta1.FieldMapping{
+				{Name: "fruit"},
+			},
+			exclude:  []string{"fruit"},
+			expectOK: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exclude := make(map[string]fieldOwner)
+			for _, field := range test.exclude {
+				exclude[field] = fieldOwner{}
+			}
+
+			mapper, ok := withoutFields(&v1beta1.VaultBindingSpec{Fields: test.mapping}, map[string]interface{}{
+				"fruit":     "banana",
+				"vegetable": "carrot",
+			}, exclude)
+
+			g.Expect(ok).To(Equal(test.expectOK))
+			if ok {
+				g.Expect(mapper.GetFieldMapping()).To(ConsistOf(test.expectMapping))
+			}
+		})
+	}
+}
+
+func TestOwnedFields(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		result      vault.WriteResult
+		expectOwned []string
+	}{
+		{
+			name: "claim added and updated fields",
+			result: vault.WriteResult{
+				Fields:  []string{"fruit", "vegetable"},
+				Added:   []string{"fruit"},
+				Updated: []string{"vegetable"},
+			},
+			expectOwned: []string{"fruit", "vegetable"},
+		},
+		{
+			name: "adopt fields which already held the value",
+			result: vault.WriteResult{
+				Fields: []string{"vegetable", "fruit"},
+				Added:  []string{"vegetable"},
+			},
+			expectOwned: []string{"fruit", "vegetable"},
+		},
+		{
+			name: "do not claim skipped fields",
+			result: vault.WriteResult{
+				Fields:  []string{"fruit"},
+				Skipped: []string{"vegetable"},
+			},
+			expectOwned: []string{"fruit"},
+		},
+		{
+			name: "nothing written",
+			result: vault.WriteResult{
+				Skipped: []string{"vegetable"},
+			},
+			expectOwned: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g.Expect(ownedFields(test.result)).To(Equal(test.expectOwned))
+		})
+	}
+}
+
+func TestMappedFields(t *testing.T) {
+	g := NewWithT(t)
+
+	srcData := map[string]interface{}{
+		"fruit":     "banana",
+		"vegetable": "carrot",
+	}
+
+	tests := []struct {
+		name         string
+		mapping      []v1beta1.FieldMapping
+		expectFields []string
+	}{
+		{
+			name:         "map all fields without field mapping",
+			expectFields: []string{"fruit", "vegetable"},
+		},
+		{
+			name: "map renamed fields",
+			mapping: []v1beta1.FieldMapping{
+				{Name: "vegetable", Rename: "root"},
+				{Name: "fruit"},
+			},
+			expectFields: []string{"fruit", "root"},
+		},
+		{
+			name: "skip mapped fields missing in the source data",
+			mapping: []v1beta1.FieldMapping{
+				{Name: "fruit"},
+				{Name: "berry", Rename: "vegetable"},
+			},
+			expectFields: []string{"fruit"},
+		},
+		{
+			name: "no fields if all mapped fields are missing",
+			mapping: []v1beta1.FieldMapping{
+				{Name: "berry"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g.Expect(mappedFields(test.mapping, srcData)).To(Equal(test.expectFields))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/metrics"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+// vaultStatus describes the result of a successful sync
+// The path falls back to the given one if nothing was written, for example if all fields are owned by other resources.
+func vaultStatus(address, path string, result vault.WriteResult, now metav1.Time) v1beta1.VaultBindingVaultStatus {
+	status := v1beta1.VaultBindingVaultStatus{
+		Address:      address,
+		Path:         result.Path,
+		Fields:       strings.Join(result.Fields, ","),
+		KVVersion:    result.KVVersion,
+		Version:      result.Version,
+		DataHash:     result.DataHash,
+		LastSyncTime: &now,
+	}
+
+	if status.Path == "" {
+		status.Path = path
+	}
+
+	return status
+}
+
+// recordResourceMetrics exposes the Bound condition and the last successful sync of a reconciled resource
+func recordResourceMetrics(kind string, obj metav1.Object, conditions []metav1.Condition, lastSync *metav1.Time) {
+	var t *time.Time
+	if lastSync != nil {
+		t = &lastSync.Time
+	}
+
+	bound := apimeta.IsStatusConditionTrue(conditions, v1beta1.BoundCondition)
+	metrics.RecordResource(kind, obj.GetNamespace(), obj.GetName(), bound, t)
+}
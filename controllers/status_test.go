@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+func TestVaultStatus(t *testing.T) {
+	g := NewWithT(t)
+	now := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name   string
+		path   string
+		result vault.WriteResult
+		expect v1beta1.VaultBindingVaultStatus
+	}{
+		{
+			name: "status of written fields",
+			path: "secret/food",
+			result: vault.WriteResult{
+				Written:   true,
+				Fields:    []string{"fruit", "vegetable"},
+				Path:      "secret/data/food",
+				KVVersion: vault.KVVersion2,
+				Version:   4,
+				DataHash:  "abc",
+			},
+			expect: v1beta1.VaultBindingVaultStatus{
+				Address:      "https://vault:8200",
+				Path:         "secret/data/food",
+				Fields:       "fruit,vegetable",
+				KVVersion:    vault.KVVersion2,
+				Version:      4,
+				DataHash:     "abc",
+				LastSyncTime: &now,
+			},
+		},
+		{
+			name: "fallback to the given path if nothing was written",
+			path: "secret/food",
+			expect: v1beta1.VaultBindingVaultStatus{
+				Address:      "https://vault:8200",
+				Path:         "secret/food",
+				LastSyncTime: &now,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g.Expect(vaultStatus("https://vault:8200", test.path, test.result, now)).To(Equal(test.expect))
+		})
+	}
+}
@@ -23,10 +23,13 @@ import (
 	"path/filepath"
 	"testing"
 
+	vaultapi "github.com/hashicorp/vault/api"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -98,6 +101,33 @@ var _ = BeforeSuite(func(done Done) {
 	}).SetupWithManager(k8sManager, VaultMirrorReconcilerOptions{})
 	Expect(err).ToNot(HaveOccurred(), "failed to setup VaultMirror")
 
+	// VaultSecret setup
+	err = (&VaultSecretReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("VaultSecret"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorderFor("VaultSecret"),
+	}).SetupWithManager(k8sManager, VaultSecretReconcilerOptions{})
+	Expect(err).ToNot(HaveOccurred(), "failed to setup VaultSecret")
+
+	// VaultConnection setup
+	err = (&VaultConnectionReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("VaultConnection"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorderFor("VaultConnection"),
+	}).SetupWithManager(k8sManager, VaultConnectionReconcilerOptions{})
+	Expect(err).ToNot(HaveOccurred(), "failed to setup VaultConnection")
+
+	// ClusterVaultConnection setup
+	err = (&ClusterVaultConnectionReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClusterVaultConnection"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorderFor("ClusterVaultConnection"),
+	}).SetupWithManager(k8sManager, ClusterVaultConnectionReconcilerOptions{})
+	Expect(err).ToNot(HaveOccurred(), "failed to setup ClusterVaultConnection")
+
 	ctx, cancel = context.WithCancel(context.TODO())
 	go func() {
 		err = k8sManager.Start(ctx)
@@ -128,6 +158,9 @@ func randStringRunes(n int) string {
 	return string(b)
 }
 
+// vaultRootToken is the root token of the vault dev server
+const vaultRootToken = "root"
+
 type vaultContainer struct {
 	testcontainers.Container
 	URI string
@@ -138,7 +171,9 @@ func setupvaultContainer(ctx context.Context) (*vaultContainer, error) {
 		Image:        "vault:1.9.0",
 		ExposedPorts: []string{"8200/tcp"},
 		WaitingFor:   wait.ForListeningPort("8200"),
-		Env:          map[string]string{},
+		Env: map[string]string{
+			"VAULT_DEV_ROOT_TOKEN_ID": vaultRootToken,
+		},
 	}
 
 	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
@@ -164,3 +199,41 @@ func setupvaultContainer(ctx context.Context) (*vaultContainer, error) {
 
 	return &vaultContainer{Container: container, URI: uri}, nil
 }
+
+// vaultTokenAuth creates a secret holding the vault root token in the namespace
+// and returns the auth settings referencing it
+func vaultTokenAuth(namespace string) infrav1beta1.VaultAuthSpec {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vault-token-" + randStringRunes(5),
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			"token": []byte(vaultRootToken),
+		},
+	}
+
+	Expect(k8sClient.Create(context.Background(), secret)).Should(Succeed())
+
+	return infrav1beta1.VaultAuthSpec{
+		Type: "token",
+		Token: &infrav1beta1.VaultTokenSpec{
+			SecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+				Key:                  "token",
+			},
+		},
+	}
+}
+
+// vaultClient returns a vault client authenticated with the root token of the container
+func (c *vaultContainer) vaultClient() *vaultapi.Client {
+	cfg := vaultapi.DefaultConfig()
+	cfg.Address = c.URI
+
+	vc, err := vaultapi.NewClient(cfg)
+	Expect(err).NotTo(HaveOccurred())
+	vc.SetToken(vaultRootToken)
+
+	return vc
+}
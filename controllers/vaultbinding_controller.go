@@ -19,6 +19,8 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -28,19 +30,28 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/audit"
+	"github.com/DoodleScheduling/k8svault-controller/internal/metrics"
+	"github.com/DoodleScheduling/k8svault-controller/internal/tracing"
 	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
 )
 
 // +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=vaultbindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=vaultbindings/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=vaultbindings/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 const (
@@ -52,9 +63,19 @@ const (
 // VaultBinding reconciles a VaultBinding object
 type VaultBindingReconciler struct {
 	client.Client
-	Log      logr.Logger
-	Scheme   *runtime.Scheme
-	Recorder record.EventRecorder
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	Recorder    record.EventRecorder
+	ClientCache *vault.ClientCache
+
+	// AuditSink receives a record for each change to vault, it is optional
+	AuditSink audit.Sink
+
+	// ControllerNamespace is the namespace the controller runs in
+	ControllerNamespace string
+
+	// TokenRequestAudiences are the audiences service account tokens may be requested for
+	TokenRequestAudiences []string
 }
 
 type VaultBindingReconcilerOptions struct {
@@ -76,13 +97,37 @@ func (r *VaultBindingReconciler) SetupWithManager(mgr ctrl.Manager, opts VaultBi
 		return err
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1beta1.VaultBinding{}).
+	// Index the VaultBinding by the vault path they write to
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1beta1.VaultBinding{}, vaultPathIndexKey,
+		func(o client.Object) []string {
+			vb := o.(*v1beta1.VaultBinding)
+			return []string{vaultPathKey(vb.GetNamespace(), vb.Spec.VaultSpec)}
+		},
+	); err != nil {
+		return err
+	}
+
+	// Status updates (lastSyncTime) must not trigger another reconcile
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.VaultBinding{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}),
+		)).
 		Watches(
 			&source.Kind{Type: &corev1.Secret{}},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
-		).
-		WithOptions(controller.Options{MaxConcurrentReconciles: opts.MaxConcurrentReconciles}).
+		)
+
+	// Reconcile the VaultBinding if a referenced connection changes
+	if err := watchConnections(mgr, b, &v1beta1.VaultBinding{}, &v1beta1.VaultBindingList{},
+		func(o client.Object) []string {
+			vb := o.(*v1beta1.VaultBinding)
+			return connectionRefs(vb.GetNamespace(), vb.Spec.VaultSpec)
+		}, r.Log,
+	); err != nil {
+		return err
+	}
+
+	return b.WithOptions(controller.Options{MaxConcurrentReconciles: opts.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
@@ -102,6 +147,10 @@ func (r *VaultBindingReconciler) requestsForSecretChange(o client.Object) []reco
 
 	var reqs []reconcile.Request
 	for _, i := range list.Items {
+		if i.Spec.Suspend {
+			continue
+		}
+
 		r.Log.Info("referenced secret from a vaultbinding changed detected, reconcile binding", "namespace", i.GetNamespace(), "name", i.GetName())
 		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
 	}
@@ -111,6 +160,9 @@ func (r *VaultBindingReconciler) requestsForSecretChange(o client.Object) []reco
 
 // Reconcile VaultBindings
 func (r *VaultBindingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "VaultBinding.Reconcile", tracing.ResourceAttributes("VaultBinding", req.Namespace, req.Name)...)
+	defer span.End()
+
 	logger := r.Log.WithValues("Namespace", req.Namespace, "Name", req.NamespacedName)
 	logger.Info("reconciling VaultBinding")
 
@@ -123,13 +175,44 @@ func (r *VaultBindingReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			metrics.DeleteResource("VaultBinding", req.Namespace, req.Name)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
 
-	binding, result, reconcileErr := r.reconcile(ctx, binding, logger)
+	if !binding.GetDeletionTimestamp().IsZero() {
+		return r.reconcileDelete(ctx, binding, logger)
+	}
+
+	// The finalizer is only required if fields are removed from vault once the binding gets deleted
+	finalizer := binding.Spec.DeletionPolicy == v1beta1.DeletionPolicyDelete
+	if finalizer != controllerutil.ContainsFinalizer(&binding, v1beta1.Finalizer) {
+		if finalizer {
+			controllerutil.AddFinalizer(&binding, v1beta1.Finalizer)
+		} else {
+			controllerutil.RemoveFinalizer(&binding, v1beta1.Finalizer)
+		}
+
+		if err := r.Client.Update(ctx, &binding); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	var result ctrl.Result
+	var reconcileErr error
+	if binding.Spec.Suspend {
+		logger.Info("skipping reconciliation, binding is suspended")
+		binding = v1beta1.VaultBindingSuspended(binding, "Reconciliation is suspended")
+	} else {
+		binding, result, reconcileErr = r.reconcile(ctx, v1beta1.VaultBindingNotSuspended(binding), logger)
+
+		if v, ok := v1beta1.ReconcileRequestedAt(&binding); ok {
+			binding.Status.LastHandledReconcileAt = v
+		}
+	}
+
 	binding.Status.ObservedGeneration = binding.GetGeneration()
 
 	// Update status after reconciliation.
@@ -138,6 +221,9 @@ func (r *VaultBindingReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{Requeue: true}, err
 	}
 
+	recordResourceMetrics("VaultBinding", &binding, binding.Status.Conditions, binding.Status.LastSyncTime)
+
+	tracing.RecordError(span, reconcileErr)
 	return result, reconcileErr
 }
 
@@ -148,7 +234,9 @@ func (r *VaultBindingReconciler) reconcile(ctx context.Context, binding v1beta1.
 		Namespace: binding.GetNamespace(),
 		Name:      binding.Spec.Secret.Name,
 	}
-	err := r.Client.Get(context.TODO(), secretName, secret)
+	secretCtx, span := tracing.Start(ctx, "GetSecret", tracing.ResourceAttributes("Secret", secretName.Namespace, secretName.Name)...)
+	err := r.Client.Get(secretCtx, secretName, secret)
+	tracing.End(span, err)
 
 	// Failed to fetch referenced secret, requeue immediately
 	if err != nil {
@@ -157,33 +245,225 @@ func (r *VaultBindingReconciler) reconcile(ctx context.Context, binding v1beta1.
 		return v1beta1.VaultBindingNotBound(binding, v1beta1.SecretNotFoundReason, msg), ctrl.Result{Requeue: true}, err
 	}
 
-	h, err := vault.NewHandler(binding.Spec.VaultSpec, logger)
+	h, err := vault.NewHandler(ctx, binding.Spec.VaultSpec, vault.HandlerOptions{
+		Client:                r.Client,
+		Namespace:             binding.GetNamespace(),
+		Cache:                 r.ClientCache,
+		ControllerNamespace:   r.ControllerNamespace,
+		TokenRequestAudiences: r.TokenRequestAudiences,
+	}, logger)
 
 	// Failed to setup vault client, requeue immediately
 	if err != nil {
 		msg := fmt.Sprintf("Connection to vault failed: %s", err.Error())
 		r.Recorder.Event(&binding, "Normal", "error", msg)
-		return v1beta1.VaultBindingNotBound(binding, v1beta1.VaultConnectionFailedReason, msg), ctrl.Result{Requeue: true}, err
+		return v1beta1.VaultBindingNotBound(binding, connectionFailedReason(err), msg), ctrl.Result{Requeue: true}, err
 	}
 
+	defer h.Close()
+	binding.Status.TLS = h.TLSStatus()
+
 	// Map k8s secret (convert to string, base64 devcode)
 	data := make(map[string]interface{})
 	for k, v := range secret.Data {
 		data[k] = string(v)
 	}
 
-	_, err = h.Write(&binding.Spec, data)
+	// Mapped fields missing in the secret are not written, they are pruned like fields which are not mapped anymore
+	var mapper vault.Mapper = &binding.Spec
+	if len(binding.Spec.Fields) > 0 {
+		mapper = &excludeMapper{Mapper: &binding.Spec, mapping: availableFields(binding.Spec.Fields, data)}
+	}
+
+	// Fields owned by other resources writing to the same path are not written
+	desired := mappedFields(binding.Spec.Fields, data)
+	conflicts, err := fieldConflicts(ctx, r.Client, &binding, binding.Status.ManagedFields, binding.Spec.VaultSpec, desired)
+	if err != nil {
+		return binding, ctrl.Result{Requeue: true}, err
+	}
+
+	if len(conflicts) > 0 {
+		msg := conflictMessage(conflicts)
+		r.Recorder.Event(&binding, "Warning", v1beta1.ConflictCondition, msg)
+		binding = v1beta1.VaultBindingConflict(binding, v1beta1.FieldConflictReason, msg)
+	} else {
+		binding = v1beta1.VaultBindingNoConflict(binding)
+	}
+
+	// Detect fields which were changed or deleted in vault since the last sync
+	mapped := mappedData(binding.Spec.Fields, data)
+	var drifted []string
+	if binding.Status.DataHash != "" {
+		current, err := h.Read(ctx, binding.Spec.Path)
+		if err != nil && err != vault.ErrPathNotFound {
+			msg := fmt.Sprintf("Failed to read path from vault: %s", err.Error())
+			r.Recorder.Event(&binding, "Normal", "error", msg)
+			return v1beta1.VaultBindingNotBound(binding, v1beta1.VaultUpdateFailedReason, msg), ctrl.Result{Requeue: true}, err
+		}
+
+		drifted, err = driftedFields(binding.Status.VaultBindingVaultStatus, mapped, current, conflicts)
+		if err != nil {
+			return binding, ctrl.Result{Requeue: true}, err
+		}
+	}
+
+	var result vault.WriteResult
+	if mapper, ok := withoutFields(mapper, data, conflicts); ok && len(desired) > 0 {
+		result, err = h.Write(ctx, mapper, data)
+	}
 
 	// Failed to setup vault client, requeue immediately
 	if err != nil {
+		reason := v1beta1.VaultUpdateFailedReason
+		if err == vault.ErrCASMismatch {
+			reason = v1beta1.VaultUpdateConflictReason
+		}
+
 		msg := fmt.Sprintf("Update vault failed: %s", err.Error())
 		r.Recorder.Event(&binding, "Normal", "error", msg)
-		return v1beta1.VaultBindingNotBound(binding, v1beta1.VaultUpdateFailedReason, msg), ctrl.Result{Requeue: true}, err
+		return v1beta1.VaultBindingNotBound(binding, reason, msg), ctrl.Result{Requeue: true}, err
+	}
+
+	actor := lastManager(&binding, secret)
+	if result.Written {
+		emitAudit(ctx, r.AuditSink, writeRecord("VaultBinding", &binding, actor, h.Address(), result), logger)
+	}
+
+	// Remove fields which were managed by the binding but are not mapped anymore
+	if binding.Spec.Prune {
+		stale, err := unclaimedFields(ctx, r.Client, &binding, binding.Spec.VaultSpec, staleFields(binding.Status.ManagedFields, desired))
+		if err != nil {
+			return binding, ctrl.Result{Requeue: true}, err
+		}
+
+		if len(stale) > 0 {
+			logger.Info("pruning fields which are not mapped anymore", "fields", stale)
+
+			removed, err := h.Remove(ctx, binding.Spec.Path, stale)
+			if err != nil {
+				reason := v1beta1.VaultUpdateFailedReason
+				if err == vault.ErrCASMismatch {
+					reason = v1beta1.VaultUpdateConflictReason
+				}
+
+				msg := fmt.Sprintf("Pruning vault fields failed: %s", err.Error())
+				r.Recorder.Event(&binding, "Normal", "error", msg)
+				return v1beta1.VaultBindingNotBound(binding, reason, msg), ctrl.Result{Requeue: true}, err
+			}
+
+			if len(removed) > 0 {
+				emitAudit(ctx, r.AuditSink, removeRecord(audit.OperationPrune, "VaultBinding", &binding, actor, h.Address(), binding.Spec.Path, removed), logger)
+			}
+		}
+	}
+
+	// Drifted fields which are not re-applied without forceApply are still managed by the binding if they were before.
+	uncorrected := staleFields(drifted, result.Fields)
+	managed := ownedFields(result)
+	for _, field := range uncorrected {
+		if contains(binding.Status.ManagedFields, field) {
+			managed = append(managed, field)
+		}
+	}
+	sort.Strings(managed)
+
+	// The data hash covers the desired value of all synced fields so drifts are reported until they are resolved
+	synced := syncedFields(result, uncorrected)
+	binding.Status.ManagedFields = managed
+	binding.Status.VaultBindingVaultStatus = vaultStatus(h.Address(), binding.Spec.Path, result, metav1.Now())
+	binding.Status.Fields = strings.Join(synced, ",")
+	binding.Status.DataHash, err = vault.DataHash(mapped, synced)
+	if err != nil {
+		return binding, ctrl.Result{Requeue: true}, err
+	}
+
+	switch {
+	case len(uncorrected) > 0:
+		msg := fmt.Sprintf("Fields were changed in vault and are not re-applied without forceApply: %s", strings.Join(uncorrected, ", "))
+		r.Recorder.Event(&binding, "Warning", v1beta1.DriftedCondition, msg)
+		binding = v1beta1.VaultBindingDrifted(binding, metav1.ConditionTrue, v1beta1.FieldsDriftedReason, msg)
+	case len(drifted) > 0:
+		msg := fmt.Sprintf("Fields changed in vault were re-applied: %s", strings.Join(drifted, ", "))
+		r.Recorder.Event(&binding, "Normal", v1beta1.DriftedCondition, msg)
+		binding = v1beta1.VaultBindingDrifted(binding, metav1.ConditionFalse, v1beta1.DriftCorrectedReason, msg)
+	default:
+		binding = v1beta1.VaultBindingNotDrifted(binding)
 	}
 
 	msg := "Vault fields successfully bound"
 	r.Recorder.Event(&binding, "Normal", "info", msg)
-	return v1beta1.VaultBindingBound(binding, v1beta1.VaultUpdateSuccessfulReason, msg), ctrl.Result{}, err
+
+	// Reqeue only if an interval is specified
+	res := ctrl.Result{}
+	if binding.Spec.Interval != nil {
+		res = ctrl.Result{RequeueAfter: binding.Spec.Interval.Duration}
+	}
+
+	return v1beta1.VaultBindingBound(binding, v1beta1.VaultUpdateSuccessfulReason, msg), res, err
+}
+
+// reconcileDelete removes the fields written by the binding from vault and releases the finalizer afterwards
+func (r *VaultBindingReconciler) reconcileDelete(ctx context.Context, binding v1beta1.VaultBinding, logger logr.Logger) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(&binding, v1beta1.Finalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if binding.Spec.DeletionPolicy == v1beta1.DeletionPolicyDelete {
+		binding, err := r.removeFields(ctx, binding, logger)
+		if err != nil {
+			if err := r.patchStatus(ctx, &binding); err != nil {
+				logger.Error(err, "unable to update status after reconciliation")
+			}
+
+			return ctrl.Result{Requeue: true}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(&binding, v1beta1.Finalizer)
+	return ctrl.Result{}, r.Client.Update(ctx, &binding)
+}
+
+func (r *VaultBindingReconciler) removeFields(ctx context.Context, binding v1beta1.VaultBinding, logger logr.Logger) (v1beta1.VaultBinding, error) {
+	// Fields also managed by other resources writing to the same path are kept
+	fields, err := unclaimedFields(ctx, r.Client, &binding, binding.Spec.VaultSpec, binding.Status.ManagedFields)
+	if err != nil {
+		return binding, err
+	}
+
+	if len(fields) == 0 {
+		logger.Info("no fields to remove from vault")
+		return binding, nil
+	}
+
+	h, err := vault.NewHandler(ctx, binding.Spec.VaultSpec, vault.HandlerOptions{
+		Client:                r.Client,
+		Namespace:             binding.GetNamespace(),
+		Cache:                 r.ClientCache,
+		ControllerNamespace:   r.ControllerNamespace,
+		TokenRequestAudiences: r.TokenRequestAudiences,
+	}, logger)
+
+	if err != nil {
+		msg := fmt.Sprintf("Connection to vault failed: %s", err.Error())
+		r.Recorder.Event(&binding, "Normal", "error", msg)
+		return v1beta1.VaultBindingNotBound(binding, connectionFailedReason(err), msg), err
+	}
+
+	defer h.Close()
+	removed, err := h.Remove(ctx, binding.Spec.Path, fields)
+	if err != nil {
+		msg := fmt.Sprintf("Removing fields from vault failed: %s", err.Error())
+		r.Recorder.Event(&binding, "Normal", "error", msg)
+		return v1beta1.VaultBindingNotBound(binding, v1beta1.VaultUpdateFailedReason, msg), err
+	}
+
+	// The deletion is not tracked by field managers, the kubernetes audit log records who deleted the binding
+	if len(removed) > 0 {
+		emitAudit(ctx, r.AuditSink, removeRecord(audit.OperationDelete, "VaultBinding", &binding, "", h.Address(), binding.Spec.Path, removed), logger)
+	}
+
+	r.Recorder.Event(&binding, "Normal", "info", "Vault fields successfully removed")
+	return binding, nil
 }
 
 func (r *VaultBindingReconciler) patchStatus(ctx context.Context, binding *v1beta1.VaultBinding) error {
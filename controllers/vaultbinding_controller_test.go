@@ -24,6 +24,8 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -40,12 +42,12 @@ var _ = Describe("VaultBindingReconciler", func() {
 	Context("VaultBinding", func() {
 		var (
 			namespace *corev1.Namespace
-			//			container *vaultContainer
-			err error
+			container *vaultContainer
+			err       error
 		//	tokenFile string
 		)
 
-		_, err = setupvaultContainer(context.TODO())
+		container, err = setupvaultContainer(context.TODO())
 		Expect(err).NotTo(HaveOccurred(), "failed to start vault container")
 
 		BeforeEach(func() {
@@ -98,6 +100,80 @@ var _ = Describe("VaultBindingReconciler", func() {
 			}, timeout, interval).Should(BeTrue())
 		})
 
+		It("records the handled reconcile request", func() {
+			key := types.NamespacedName{
+				Name:      "vaultbinding-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+			created := &infrav1beta1.VaultBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					Annotations: map[string]string{
+						infrav1beta1.ReconcileRequestAnnotation: "first",
+					},
+				},
+				Spec: infrav1beta1.VaultBindingSpec{
+					VaultSpec: &infrav1beta1.VaultSpec{
+						Address: "https://does-not-exists",
+						Path:    "/dest/not-found",
+					},
+					Secret: &corev1.SecretReference{
+						Name: "does-not-exists",
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), created)).Should(Succeed())
+
+			got := &infrav1beta1.VaultBinding{}
+			Eventually(func() string {
+				_ = k8sClient.Get(context.Background(), key, got)
+				return got.Status.LastHandledReconcileAt
+			}, timeout, interval).Should(Equal("first"))
+
+			By("Requesting another reconcile")
+			got.Annotations[infrav1beta1.ReconcileRequestAnnotation] = "second"
+			Expect(k8sClient.Update(context.Background(), got)).Should(Succeed())
+
+			Eventually(func() string {
+				_ = k8sClient.Get(context.Background(), key, got)
+				return got.Status.LastHandledReconcileAt
+			}, timeout, interval).Should(Equal("second"))
+		})
+
+		It("does not read the secret if suspended", func() {
+			key := types.NamespacedName{
+				Name:      "vaultbinding-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+			created := &infrav1beta1.VaultBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+				},
+				Spec: infrav1beta1.VaultBindingSpec{
+					Suspend: true,
+					VaultSpec: &infrav1beta1.VaultSpec{
+						Address: "https://does-not-exists",
+						Path:    "/dest/not-found",
+					},
+					Secret: &corev1.SecretReference{
+						Name: "does-not-exists",
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), created)).Should(Succeed())
+
+			got := &infrav1beta1.VaultBinding{}
+			Eventually(func() bool {
+				_ = k8sClient.Get(context.Background(), key, got)
+				return len(got.Status.Conditions) == 1 &&
+					got.Status.Conditions[0].Reason == infrav1beta1.SuspendedReason &&
+					got.Status.Conditions[0].Status == "True" &&
+					got.Status.Conditions[0].Type == infrav1beta1.SuspendedCondition
+			}, timeout, interval).Should(BeTrue())
+		})
+
 		It("fails if vault can't be contacted", func() {
 			By("Adding secret")
 			keySecret := types.NamespacedName{
@@ -148,5 +224,166 @@ var _ = Describe("VaultBindingReconciler", func() {
 					got.Status.Conditions[0].Type == infrav1beta1.BoundCondition
 			}, timeout, interval).Should(BeTrue())
 		})
+
+		It("reports the vault status", func() {
+			By("Adding secret")
+			keySecret := types.NamespacedName{
+				Name:      "secret-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+
+			createdSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      keySecret.Name,
+					Namespace: keySecret.Namespace,
+				},
+				Data: map[string][]byte{
+					"berries": []byte(randStringRunes(5)),
+				},
+			}
+
+			Expect(k8sClient.Create(context.Background(), createdSecret)).Should(Succeed())
+
+			key := types.NamespacedName{
+				Name:      "vaultbinding-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+			created := &infrav1beta1.VaultBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+				},
+				Spec: infrav1beta1.VaultBindingSpec{
+					VaultSpec: &infrav1beta1.VaultSpec{
+						Address: container.URI,
+						Path:    "/secret/" + key.Name,
+						Auth:    vaultTokenAuth(namespace.Name),
+					},
+					Secret: &corev1.SecretReference{
+						Name: keySecret.Name,
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), created)).Should(Succeed())
+
+			got := &infrav1beta1.VaultBinding{}
+			Eventually(func() bool {
+				_ = k8sClient.Get(context.Background(), key, got)
+				return apimeta.IsStatusConditionTrue(got.Status.Conditions, infrav1beta1.BoundCondition)
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(got.Status.DataHash).NotTo(BeEmpty())
+			Expect(got.Status.Path).To(Equal("secret/data/" + key.Name))
+			Expect(got.Status.Fields).To(Equal("berries"))
+			Expect(got.Status.LastSyncTime).NotTo(BeNil())
+		})
+
+		It("detects fields changed in vault", func() {
+			By("Adding secret")
+			keySecret := types.NamespacedName{
+				Name:      "secret-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+
+			createdSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      keySecret.Name,
+					Namespace: keySecret.Namespace,
+				},
+				Data: map[string][]byte{
+					"berries": []byte(randStringRunes(5)),
+				},
+			}
+
+			Expect(k8sClient.Create(context.Background(), createdSecret)).Should(Succeed())
+
+			key := types.NamespacedName{
+				Name:      "vaultbinding-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+			created := &infrav1beta1.VaultBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					Annotations: map[string]string{
+						infrav1beta1.ReconcileRequestAnnotation: "first",
+					},
+				},
+				Spec: infrav1beta1.VaultBindingSpec{
+					VaultSpec: &infrav1beta1.VaultSpec{
+						Address: container.URI,
+						Path:    "/secret/" + key.Name,
+						Auth:    vaultTokenAuth(namespace.Name),
+					},
+					Secret: &corev1.SecretReference{
+						Name: keySecret.Name,
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), created)).Should(Succeed())
+
+			got := &infrav1beta1.VaultBinding{}
+			Eventually(func() bool {
+				_ = k8sClient.Get(context.Background(), key, got)
+				return apimeta.IsStatusConditionTrue(got.Status.Conditions, infrav1beta1.BoundCondition) &&
+					got.Status.DataHash != ""
+			}, timeout, interval).Should(BeTrue())
+
+			By("Changing the field in vault")
+			_, err := container.vaultClient().KVv2("secret").Put(context.Background(), key.Name, map[string]interface{}{
+				"berries": "changed",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Requesting another reconcile")
+			got.Annotations[infrav1beta1.ReconcileRequestAnnotation] = "second"
+			Expect(k8sClient.Update(context.Background(), got)).Should(Succeed())
+
+			Eventually(func() bool {
+				_ = k8sClient.Get(context.Background(), key, got)
+				drifted := apimeta.FindStatusCondition(got.Status.Conditions, infrav1beta1.DriftedCondition)
+				return drifted != nil &&
+					drifted.Status == metav1.ConditionTrue &&
+					drifted.Reason == infrav1beta1.FieldsDriftedReason
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("adds a finalizer if the deletion policy is Delete", func() {
+			key := types.NamespacedName{
+				Name:      "vaultbinding-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+			created := &infrav1beta1.VaultBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+				},
+				Spec: infrav1beta1.VaultBindingSpec{
+					VaultSpec: &infrav1beta1.VaultSpec{
+						Address: "https://does-not-exists",
+						Path:    "/dest/not-found",
+					},
+					Secret: &corev1.SecretReference{
+						Name: "does-not-exists",
+					},
+					DeletionPolicy: infrav1beta1.DeletionPolicyDelete,
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), created)).Should(Succeed())
+
+			got := &infrav1beta1.VaultBinding{}
+			Eventually(func() bool {
+				_ = k8sClient.Get(context.Background(), key, got)
+				return len(got.Finalizers) == 1 &&
+					got.Finalizers[0] == infrav1beta1.Finalizer
+			}, timeout, interval).Should(BeTrue())
+
+			By("Deleting the binding the finalizer gets released")
+			Expect(k8sClient.Delete(context.Background(), got)).Should(Succeed())
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), key, got)
+				return apierrors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+		})
 	})
 })
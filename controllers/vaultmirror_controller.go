@@ -19,31 +19,52 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/audit"
+	"github.com/DoodleScheduling/k8svault-controller/internal/metrics"
+	"github.com/DoodleScheduling/k8svault-controller/internal/tracing"
 	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
 )
 
 // +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=vaultmirrors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=vaultmirrors/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=vaultmirrors/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // VaultMirror reconciles a VaultMirror object
 type VaultMirrorReconciler struct {
 	client.Client
-	Log      logr.Logger
-	Scheme   *runtime.Scheme
-	Recorder record.EventRecorder
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	Recorder    record.EventRecorder
+	ClientCache *vault.ClientCache
+
+	// AuditSink receives a record for each change to vault, it is optional
+	AuditSink audit.Sink
+
+	// ControllerNamespace is the namespace the controller runs in
+	ControllerNamespace string
+
+	// TokenRequestAudiences are the audiences service account tokens may be requested for
+	TokenRequestAudiences []string
 }
 
 type VaultMirrorReconcilerOptions struct {
@@ -52,14 +73,41 @@ type VaultMirrorReconcilerOptions struct {
 
 // SetupWithManager adding controllers
 func (r *VaultMirrorReconciler) SetupWithManager(mgr ctrl.Manager, opts VaultMirrorReconcilerOptions) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1beta1.VaultMirror{}).
-		WithOptions(controller.Options{MaxConcurrentReconciles: opts.MaxConcurrentReconciles}).
+	// Index the VaultMirror by the vault path they write to
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1beta1.VaultMirror{}, vaultPathIndexKey,
+		func(o client.Object) []string {
+			vm := o.(*v1beta1.VaultMirror)
+			return []string{vaultPathKey(vm.GetNamespace(), vm.Spec.Destination)}
+		},
+	); err != nil {
+		return err
+	}
+
+	// Status updates (lastSyncTime) must not trigger another reconcile
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.VaultMirror{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}),
+		))
+
+	// Reconcile the VaultMirror if a referenced connection changes
+	if err := watchConnections(mgr, b, &v1beta1.VaultMirror{}, &v1beta1.VaultMirrorList{},
+		func(o client.Object) []string {
+			vm := o.(*v1beta1.VaultMirror)
+			return connectionRefs(vm.GetNamespace(), vm.Spec.Source, vm.Spec.Destination)
+		}, r.Log,
+	); err != nil {
+		return err
+	}
+
+	return b.WithOptions(controller.Options{MaxConcurrentReconciles: opts.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
 // Reconcile VaultMirrors
 func (r *VaultMirrorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "VaultMirror.Reconcile", tracing.ResourceAttributes("VaultMirror", req.Namespace, req.Name)...)
+	defer span.End()
+
 	logger := r.Log.WithValues("Namespace", req.Namespace, "Name", req.NamespacedName)
 	logger.Info("reconciling VaultMirror")
 
@@ -72,13 +120,27 @@ func (r *VaultMirrorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			metrics.DeleteResource("VaultMirror", req.Namespace, req.Name)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
 
-	mirror, result, reconcileErr := r.reconcile(ctx, mirror, logger)
+	// A suspended mirror is not requeued until it gets resumed
+	var result ctrl.Result
+	var reconcileErr error
+	if mirror.Spec.Suspend {
+		logger.Info("skipping reconciliation, mirror is suspended")
+		mirror = v1beta1.VaultMirrorSuspended(mirror, "Reconciliation is suspended")
+	} else {
+		mirror, result, reconcileErr = r.reconcile(ctx, v1beta1.VaultMirrorNotSuspended(mirror), logger)
+
+		if v, ok := v1beta1.ReconcileRequestedAt(&mirror); ok {
+			mirror.Status.LastHandledReconcileAt = v
+		}
+	}
+
 	mirror.Status.ObservedGeneration = mirror.GetGeneration()
 
 	// Update status after reconciliation.
@@ -87,29 +149,49 @@ func (r *VaultMirrorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{Requeue: true}, err
 	}
 
+	recordResourceMetrics("VaultMirror", &mirror, mirror.Status.Conditions, mirror.Status.LastSyncTime)
+
+	tracing.RecordError(span, reconcileErr)
 	return result, reconcileErr
 }
 
 func (r *VaultMirrorReconciler) reconcile(ctx context.Context, mirror v1beta1.VaultMirror, logger logr.Logger) (v1beta1.VaultMirror, ctrl.Result, error) {
-	srcHandler, err := vault.NewHandler(mirror.Spec.Source, logger)
+	opts := vault.HandlerOptions{
+		Client:                r.Client,
+		Namespace:             mirror.GetNamespace(),
+		Cache:                 r.ClientCache,
+		ControllerNamespace:   r.ControllerNamespace,
+		TokenRequestAudiences: r.TokenRequestAudiences,
+	}
+
+	srcHandler, err := vault.NewHandler(ctx, mirror.Spec.Source, opts, logger)
 
 	// Failed to setup vault client, requeue immediately
 	if err != nil {
 		msg := fmt.Sprintf("Connection to source vault failed: %s", err.Error())
 		r.Recorder.Event(&mirror, "Normal", "error", msg)
-		return v1beta1.VaultMirrorNotBound(mirror, v1beta1.VaultConnectionFailedReason, msg), ctrl.Result{Requeue: true}, err
+		return v1beta1.VaultMirrorNotBound(mirror, connectionFailedReason(err), msg), ctrl.Result{Requeue: true}, err
 	}
 
-	dstHandler, err := vault.NewHandler(mirror.Spec.Destination, logger)
+	defer srcHandler.Close()
+	dstHandler, err := vault.NewHandler(ctx, mirror.Spec.Destination, opts, logger)
 
 	// Failed to setup vault client, requeue immediately
 	if err != nil {
 		msg := fmt.Sprintf("Connection to destination vault failed: %s", err.Error())
 		r.Recorder.Event(&mirror, "Normal", "error", msg)
-		return v1beta1.VaultMirrorNotBound(mirror, v1beta1.VaultConnectionFailedReason, msg), ctrl.Result{Requeue: true}, err
+		return v1beta1.VaultMirrorNotBound(mirror, connectionFailedReason(err), msg), ctrl.Result{Requeue: true}, err
 	}
 
-	data, err := srcHandler.Read(mirror.Spec.Source.Path)
+	defer dstHandler.Close()
+	mirror.Status.SourceTLS = srcHandler.TLSStatus()
+	mirror.Status.DestinationTLS = dstHandler.TLSStatus()
+
+	if mirror.Spec.Recursive {
+		return r.reconcileRecursive(ctx, mirror, srcHandler, dstHandler, logger)
+	}
+
+	data, err := srcHandler.Read(ctx, mirror.Spec.Source.Path)
 
 	// Failed to read source vault, requeue immediately
 	if err != nil {
@@ -118,15 +200,44 @@ func (r *VaultMirrorReconciler) reconcile(ctx context.Context, mirror v1beta1.Va
 		return v1beta1.VaultMirrorNotBound(mirror, v1beta1.VaultReadSourceFailedReason, msg), ctrl.Result{Requeue: true}, err
 	}
 
-	_, err = dstHandler.Write(&mirror.Spec, data)
+	// Fields owned by other resources writing to the same path are not written
+	conflicts, err := fieldConflicts(ctx, r.Client, &mirror, mirror.Status.ManagedFields, mirror.Spec.Destination, mappedFields(mirror.Spec.Fields, data))
+	if err != nil {
+		return mirror, ctrl.Result{Requeue: true}, err
+	}
+
+	if len(conflicts) > 0 {
+		msg := conflictMessage(conflicts)
+		r.Recorder.Event(&mirror, "Warning", v1beta1.ConflictCondition, msg)
+		mirror = v1beta1.VaultMirrorConflict(mirror, v1beta1.FieldConflictReason, msg)
+	} else {
+		mirror = v1beta1.VaultMirrorNoConflict(mirror)
+	}
+
+	var writeResult vault.WriteResult
+	if mapper, ok := withoutFields(&mirror.Spec, data, conflicts); ok {
+		writeResult, err = dstHandler.Write(ctx, mapper, data)
+	}
 
 	// Failed to setup vault client, requeue immediately
 	if err != nil {
+		reason := v1beta1.VaultUpdateFailedReason
+		if err == vault.ErrCASMismatch {
+			reason = v1beta1.VaultUpdateConflictReason
+		}
+
 		msg := fmt.Sprintf("Update vault failed: %s", err.Error())
 		r.Recorder.Event(&mirror, "Normal", "error", msg)
-		return v1beta1.VaultMirrorNotBound(mirror, v1beta1.VaultUpdateFailedReason, msg), ctrl.Result{Requeue: true}, err
+		return v1beta1.VaultMirrorNotBound(mirror, reason, msg), ctrl.Result{Requeue: true}, err
 	}
 
+	if writeResult.Written {
+		emitAudit(ctx, r.AuditSink, writeRecord("VaultMirror", &mirror, lastManager(&mirror), dstHandler.Address(), writeResult), logger)
+	}
+
+	mirror.Status.ManagedFields = ownedFields(writeResult)
+	mirror.Status.VaultMirrorVaultStatus = v1beta1.VaultMirrorVaultStatus(vaultStatus(dstHandler.Address(), mirror.Spec.Destination.Path, writeResult, metav1.Now()))
+
 	msg := "Vault fields successfully bound"
 	r.Recorder.Event(&mirror, "Normal", "info", msg)
 
@@ -139,6 +250,151 @@ func (r *VaultMirrorReconciler) reconcile(ctx context.Context, mirror v1beta1.Va
 	return v1beta1.VaultMirrorBound(mirror, v1beta1.VaultUpdateSuccessfulReason, msg), result, err
 }
 
+// reconcileRecursive mirrors all secrets below the source path to the destination path
+func (r *VaultMirrorReconciler) reconcileRecursive(ctx context.Context, mirror v1beta1.VaultMirror, srcHandler, dstHandler *vault.VaultHandler, logger logr.Logger) (v1beta1.VaultMirror, ctrl.Result, error) {
+	paths, err := srcHandler.Walk(ctx, mirror.Spec.Source.Path)
+
+	// Failed to list source vault, requeue immediately
+	if err != nil {
+		msg := fmt.Sprintf("Failed to list path from source vault: %s", err.Error())
+		r.Recorder.Event(&mirror, "Normal", "error", msg)
+		return v1beta1.VaultMirrorNotBound(mirror, v1beta1.VaultReadSourceFailedReason, msg), ctrl.Result{Requeue: true}, err
+	}
+
+	var mirrored int
+	conflicts := make(map[string]fieldOwner)
+	for _, rel := range paths {
+		if !matchPaths(mirror.Spec.Include, rel, true) || matchPaths(mirror.Spec.Exclude, rel, false) {
+			logger.Info("skipping path, filtered", "path", rel)
+			continue
+		}
+
+		srcPath := path.Join(mirror.Spec.Source.Path, rel)
+		data, err := srcHandler.Read(ctx, srcPath)
+
+		// A kv version 2 secret may be deleted but still listed
+		if err == vault.ErrPathNotFound {
+			continue
+		}
+
+		if err != nil {
+			msg := fmt.Sprintf("Failed to read path %s from source vault: %s", srcPath, err.Error())
+			r.Recorder.Event(&mirror, "Normal", "error", msg)
+			return v1beta1.VaultMirrorNotBound(mirror, v1beta1.VaultReadSourceFailedReason, msg), ctrl.Result{Requeue: true}, err
+		}
+
+		// The field mapping only applies to the fields a secret has
+		mapping := availableFields(mirror.Spec.Fields, data)
+		if len(mirror.Spec.Fields) > 0 && len(mapping) == 0 {
+			logger.Info("skipping path, no mapped fields", "path", rel)
+			continue
+		}
+
+		dstPath := path.Join(mirror.Spec.Destination.Path, rel)
+		dstSpec := *mirror.Spec.Destination
+		dstSpec.Path = dstPath
+
+		// Recursive mirrors do not claim fields, fields managed by other resources writing to the path are not written
+		pathConflicts, err := fieldConflicts(ctx, r.Client, &mirror, nil, &dstSpec, mappedFields(mapping, data))
+		if err != nil {
+			return mirror, ctrl.Result{Requeue: true}, err
+		}
+
+		for field, owner := range pathConflicts {
+			conflicts[path.Join(rel, field)] = owner
+		}
+
+		mapper, ok := withoutFields(&pathMapper{Mapper: &excludeMapper{Mapper: &mirror.Spec, mapping: mapping}, path: dstPath}, data, pathConflicts)
+		if !ok {
+			continue
+		}
+
+		writeResult, err := dstHandler.Write(ctx, mapper, data)
+		if err != nil {
+			reason := v1beta1.VaultUpdateFailedReason
+			if err == vault.ErrCASMismatch {
+				reason = v1beta1.VaultUpdateConflictReason
+			}
+
+			msg := fmt.Sprintf("Update vault path %s failed: %s", dstPath, err.Error())
+			r.Recorder.Event(&mirror, "Normal", "error", msg)
+			return v1beta1.VaultMirrorNotBound(mirror, reason, msg), ctrl.Result{Requeue: true}, err
+		}
+
+		if writeResult.Written {
+			emitAudit(ctx, r.AuditSink, writeRecord("VaultMirror", &mirror, lastManager(&mirror), dstHandler.Address(), writeResult), logger)
+		}
+
+		mirrored++
+	}
+
+	if len(conflicts) > 0 {
+		msg := conflictMessage(conflicts)
+		r.Recorder.Event(&mirror, "Warning", v1beta1.ConflictCondition, msg)
+		mirror = v1beta1.VaultMirrorConflict(mirror, v1beta1.FieldConflictReason, msg)
+	} else {
+		mirror = v1beta1.VaultMirrorNoConflict(mirror)
+	}
+
+	// Field ownership is only tracked for single paths
+	mirror.Status.ManagedFields = nil
+
+	// Recursive mirrors write to many paths, only the destination base path is reported
+	mirror.Status.VaultMirrorVaultStatus = v1beta1.VaultMirrorVaultStatus(vaultStatus(dstHandler.Address(), mirror.Spec.Destination.Path, vault.WriteResult{}, metav1.Now()))
+
+	msg := fmt.Sprintf("Vault fields of %d paths successfully bound", mirrored)
+	r.Recorder.Event(&mirror, "Normal", "info", msg)
+
+	// Reqeue only if an interval is specified
+	result := ctrl.Result{}
+	if mirror.Spec.Interval != nil {
+		result = ctrl.Result{RequeueAfter: mirror.Spec.Interval.Duration}
+	}
+
+	return v1beta1.VaultMirrorBound(mirror, v1beta1.VaultUpdateSuccessfulReason, msg), result, nil
+}
+
+// pathMapper maps the fields of the wrapped mapper to a different path
+type pathMapper struct {
+	vault.Mapper
+	path string
+}
+
+func (m *pathMapper) GetPath() string {
+	return m.path
+}
+
+// availableFields returns the field mappings of the fields present in the data
+func availableFields(mapping []v1beta1.FieldMapping, data map[string]interface{}) []v1beta1.FieldMapping {
+	var available []v1beta1.FieldMapping
+	for _, field := range mapping {
+		if _, ok := data[field.Name]; ok {
+			available = append(available, field)
+		}
+	}
+
+	return available
+}
+
+// matchPaths returns true if the path or any of its parent directories matches one of the glob patterns
+// If no patterns are given empty is returned.
+func matchPaths(patterns []string, p string, empty bool) bool {
+	if len(patterns) == 0 {
+		return empty
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.Trim(pattern, "/")
+		for dir := p; dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if ok, _ := path.Match(pattern, dir); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func (r *VaultMirrorReconciler) patchStatus(ctx context.Context, mirror *v1beta1.VaultMirror) error {
 	key := client.ObjectKeyFromObject(mirror)
 	latest := &v1beta1.VaultMirror{}
@@ -19,11 +19,13 @@ package controllers
 import (
 	"context"
 	"os"
+	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -40,12 +42,12 @@ var _ = Describe("VaultMirrorReconciler", func() {
 	Context("VaultMirror", func() {
 		var (
 			namespace *corev1.Namespace
-			//			container *vaultContainer
-			err error
+			container *vaultContainer
+			err       error
 		//	tokenFile string
 		)
 
-		_, err = setupvaultContainer(context.TODO())
+		container, err = setupvaultContainer(context.TODO())
 		Expect(err).NotTo(HaveOccurred(), "failed to start vault container")
 
 		BeforeEach(func() {
@@ -98,5 +100,183 @@ var _ = Describe("VaultMirrorReconciler", func() {
 					got.Status.Conditions[0].Type == infrav1beta1.BoundCondition
 			}, timeout, interval).Should(BeTrue())
 		})
+
+		It("reports the vault status", func() {
+			key := types.NamespacedName{
+				Name:      "vaultmirror-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+
+			By("Writing the source path")
+			_, err := container.vaultClient().KVv2("secret").Put(context.Background(), "source-"+key.Name, map[string]interface{}{
+				"berries": randStringRunes(5),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			auth := vaultTokenAuth(namespace.Name)
+			created := &infrav1beta1.VaultMirror{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+				},
+				Spec: infrav1beta1.VaultMirrorSpec{
+					Destination: &infrav1beta1.VaultSpec{
+						Address: container.URI,
+						Path:    "/secret/" + key.Name,
+						Auth:    auth,
+					},
+					Source: &infrav1beta1.VaultSpec{
+						Address: container.URI,
+						Path:    "/secret/source-" + key.Name,
+						Auth:    auth,
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), created)).Should(Succeed())
+
+			got := &infrav1beta1.VaultMirror{}
+			Eventually(func() bool {
+				_ = k8sClient.Get(context.Background(), key, got)
+				return apimeta.IsStatusConditionTrue(got.Status.Conditions, infrav1beta1.BoundCondition)
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(got.Status.DataHash).NotTo(BeEmpty())
+			Expect(got.Status.Path).To(Equal("secret/data/" + key.Name))
+			Expect(got.Status.Fields).To(Equal("berries"))
+			Expect(got.Status.LastSyncTime).NotTo(BeNil())
+		})
+
+		It("does not contact vault if suspended", func() {
+			key := types.NamespacedName{
+				Name:      "vaultmirror-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+			created := &infrav1beta1.VaultMirror{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+				},
+				Spec: infrav1beta1.VaultMirrorSpec{
+					Suspend: true,
+					Destination: &infrav1beta1.VaultSpec{
+						Address: "https://does-not-exists",
+						Path:    "/dest/not-found",
+					},
+					Source: &infrav1beta1.VaultSpec{
+						Address: "https://does-not-exists",
+						Path:    "/source/not-found",
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), created)).Should(Succeed())
+
+			got := &infrav1beta1.VaultMirror{}
+			Eventually(func() bool {
+				_ = k8sClient.Get(context.Background(), key, got)
+				return len(got.Status.Conditions) == 1 &&
+					got.Status.Conditions[0].Reason == infrav1beta1.SuspendedReason &&
+					got.Status.Conditions[0].Status == "True" &&
+					got.Status.Conditions[0].Type == infrav1beta1.SuspendedCondition
+			}, timeout, interval).Should(BeTrue())
+		})
 	})
 })
+
+func TestMatchPaths(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		empty    bool
+		expect   bool
+	}{
+		{
+			name:   "no patterns",
+			path:   "db/password",
+			empty:  true,
+			expect: true,
+		},
+		{
+			name:     "match full path",
+			patterns: []string{"db/*"},
+			path:     "db/password",
+			expect:   true,
+		},
+		{
+			name:     "match parent directory",
+			patterns: []string{"db"},
+			path:     "db/primary/password",
+			expect:   true,
+		},
+		{
+			name:     "wildcard does not match across directories",
+			patterns: []string{"*/password"},
+			path:     "db/primary/password",
+			expect:   false,
+		},
+		{
+			name:     "match any pattern",
+			patterns: []string{"api/*", "/db/"},
+			path:     "db/password",
+			expect:   true,
+		},
+		{
+			name:     "no match",
+			patterns: []string{"api/*"},
+			path:     "db/password",
+			expect:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g.Expect(matchPaths(test.patterns, test.path, test.empty)).To(Equal(test.expect))
+		})
+	}
+}
+
+func TestAvailableFields(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name    string
+		mapping []infrav1beta1.FieldMapping
+		expect  []infrav1beta1.FieldMapping
+	}{
+		{
+			name: "no field mapping",
+		},
+		{
+			name: "keep fields present in the data",
+			mapping: []infrav1beta1.FieldMapping{
+				{Name: "fruit", Rename: "FRUIT"},
+				{Name: "amount"},
+			},
+			expect: []infrav1beta1.FieldMapping{
+				{Name: "fruit", Rename: "FRUIT"},
+				{Name: "amount"},
+			},
+		},
+		{
+			name: "skip fields missing in the data",
+			mapping: []infrav1beta1.FieldMapping{
+				{Name: "fruit"},
+				{Name: "vegetable", Rename: "fruit"},
+			},
+			expect: []infrav1beta1.FieldMapping{
+				{Name: "fruit"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g.Expect(availableFields(test.mapping, map[string]interface{}{
+				"fruit":  "banana",
+				"amount": "3",
+			})).To(Equal(test.expect))
+		})
+	}
+}
@@ -0,0 +1,282 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/metrics"
+	"github.com/DoodleScheduling/k8svault-controller/internal/tracing"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
+)
+
+// +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=vaultsecrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=vaultsecrets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vault.infra.doodle.com,resources=vaultsecrets/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// VaultSecret reconciles a VaultSecret object
+type VaultSecretReconciler struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	Recorder    record.EventRecorder
+	ClientCache *vault.ClientCache
+
+	// ControllerNamespace is the namespace the controller runs in
+	ControllerNamespace string
+
+	// TokenRequestAudiences are the audiences service account tokens may be requested for
+	TokenRequestAudiences []string
+}
+
+type VaultSecretReconcilerOptions struct {
+	MaxConcurrentReconciles int
+}
+
+// SetupWithManager adding controllers
+func (r *VaultSecretReconciler) SetupWithManager(mgr ctrl.Manager, opts VaultSecretReconcilerOptions) error {
+	// Status updates must not trigger another reconcile
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.VaultSecret{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}),
+		)).
+		Owns(&corev1.Secret{})
+
+	// Reconcile the VaultSecret if a referenced connection changes
+	if err := watchConnections(mgr, b, &v1beta1.VaultSecret{}, &v1beta1.VaultSecretList{},
+		func(o client.Object) []string {
+			vs := o.(*v1beta1.VaultSecret)
+			return connectionRefs(vs.GetNamespace(), vs.Spec.VaultSpec)
+		}, r.Log,
+	); err != nil {
+		return err
+	}
+
+	return b.WithOptions(controller.Options{MaxConcurrentReconciles: opts.MaxConcurrentReconciles}).
+		Complete(r)
+}
+
+// Reconcile VaultSecrets
+func (r *VaultSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "VaultSecret.Reconcile", tracing.ResourceAttributes("VaultSecret", req.Namespace, req.Name)...)
+	defer span.End()
+
+	logger := r.Log.WithValues("Namespace", req.Namespace, "Name", req.NamespacedName)
+	logger.Info("reconciling VaultSecret")
+
+	// Fetch the VaultSecret instance
+	vs := v1beta1.VaultSecret{}
+
+	err := r.Client.Get(ctx, req.NamespacedName, &vs)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			metrics.DeleteResource("VaultSecret", req.Namespace, req.Name)
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	vs, result, reconcileErr := r.reconcile(ctx, vs, logger)
+	if v, ok := v1beta1.ReconcileRequestedAt(&vs); ok {
+		vs.Status.LastHandledReconcileAt = v
+	}
+
+	vs.Status.ObservedGeneration = vs.GetGeneration()
+
+	// Update status after reconciliation.
+	if err = r.patchStatus(ctx, &vs); err != nil {
+		logger.Error(err, "unable to update status after reconciliation")
+		return ctrl.Result{Requeue: true}, err
+	}
+
+	recordResourceMetrics("VaultSecret", &vs, vs.Status.Conditions, vs.Status.LastSyncTime)
+
+	tracing.RecordError(span, reconcileErr)
+	return result, reconcileErr
+}
+
+func (r *VaultSecretReconciler) reconcile(ctx context.Context, vs v1beta1.VaultSecret, logger logr.Logger) (v1beta1.VaultSecret, ctrl.Result, error) {
+	h, err := vault.NewHandler(ctx, vs.Spec.VaultSpec, vault.HandlerOptions{
+		Client:                r.Client,
+		Namespace:             vs.GetNamespace(),
+		Cache:                 r.ClientCache,
+		ControllerNamespace:   r.ControllerNamespace,
+		TokenRequestAudiences: r.TokenRequestAudiences,
+	}, logger)
+
+	// Failed to setup vault client, requeue immediately
+	if err != nil {
+		msg := fmt.Sprintf("Connection to vault failed: %s", err.Error())
+		r.Recorder.Event(&vs, "Normal", "error", msg)
+		return v1beta1.VaultSecretNotBound(vs, connectionFailedReason(err), msg), ctrl.Result{Requeue: true}, err
+	}
+
+	defer h.Close()
+	data, err := h.Read(ctx, vs.Spec.Path)
+
+	// The path may be created later, check again with the next interval
+	if err == vault.ErrPathNotFound {
+		msg := fmt.Sprintf("Vault path %s does not exist", vs.Spec.Path)
+		r.Recorder.Event(&vs, "Normal", "error", msg)
+
+		result := ctrl.Result{}
+		if vs.Spec.Interval != nil {
+			result = ctrl.Result{RequeueAfter: vs.Spec.Interval.Duration}
+		}
+
+		return v1beta1.VaultSecretNotBound(vs, v1beta1.VaultReadSourceFailedReason, msg), result, nil
+	}
+
+	// Failed to read vault path, requeue immediately
+	if err != nil {
+		msg := fmt.Sprintf("Failed to read path from vault: %s", err.Error())
+		r.Recorder.Event(&vs, "Normal", "error", msg)
+		return v1beta1.VaultSecretNotBound(vs, v1beta1.VaultReadSourceFailedReason, msg), ctrl.Result{Requeue: true}, err
+	}
+
+	secretData, err := mapSecretData(vs.Spec.Fields, data)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to map vault fields: %s", err.Error())
+		r.Recorder.Event(&vs, "Normal", "error", msg)
+		return v1beta1.VaultSecretNotBound(vs, v1beta1.SecretUpdateFailedReason, msg), ctrl.Result{Requeue: true}, err
+	}
+
+	if err := r.applySecret(ctx, vs, secretData); err != nil {
+		msg := fmt.Sprintf("Update secret failed: %s", err.Error())
+		r.Recorder.Event(&vs, "Normal", "error", msg)
+		return v1beta1.VaultSecretNotBound(vs, v1beta1.SecretUpdateFailedReason, msg), ctrl.Result{Requeue: true}, err
+	}
+
+	now := metav1.Now()
+	vs.Status.LastSyncTime = &now
+
+	msg := "Secret successfully updated from vault"
+	r.Recorder.Event(&vs, "Normal", "info", msg)
+
+	// Reqeue only if an interval is specified
+	result := ctrl.Result{}
+	if vs.Spec.Interval != nil {
+		result = ctrl.Result{RequeueAfter: vs.Spec.Interval.Duration}
+	}
+
+	return v1beta1.VaultSecretBound(vs, v1beta1.SecretUpdateSuccessfulReason, msg), result, nil
+}
+
+// applySecret creates or updates the secret owned by the VaultSecret
+// An existing secret which is not owned by the VaultSecret is not taken over.
+func (r *VaultSecretReconciler) applySecret(ctx context.Context, vs v1beta1.VaultSecret, data map[string][]byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vs.GetSecretName(),
+			Namespace: vs.GetNamespace(),
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if !secret.CreationTimestamp.IsZero() && !metav1.IsControlledBy(secret, &vs) {
+			return fmt.Errorf("secret %s already exists and is not owned by the VaultSecret", secret.GetName())
+		}
+
+		if secret.CreationTimestamp.IsZero() {
+			secret.Type = vs.Spec.Target.Type
+		}
+
+		secret.Data = data
+		return controllerutil.SetControllerReference(&vs, secret, r.Scheme)
+	})
+
+	return err
+}
+
+// mapSecretData applies the field mapping to the vault data
+// Without a field mapping all vault fields are mapped with their name.
+// Fields with a null value are skipped.
+func mapSecretData(mapping []v1beta1.FieldMapping, data map[string]interface{}) (map[string][]byte, error) {
+	if len(mapping) == 0 {
+		for k := range data {
+			mapping = append(mapping, v1beta1.FieldMapping{
+				Name: k,
+			})
+		}
+	}
+
+	secretData := make(map[string][]byte)
+	for _, field := range mapping {
+		dstField := field.Name
+		if field.Rename != "" {
+			dstField = field.Rename
+		}
+
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", vault.ErrFieldNotAvailable, field.Name)
+		}
+
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			secretData[dstField] = []byte(v)
+		case json.Number:
+			secretData[dstField] = []byte(v.String())
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+
+			secretData[dstField] = b
+		}
+	}
+
+	return secretData, nil
+}
+
+func (r *VaultSecretReconciler) patchStatus(ctx context.Context, vs *v1beta1.VaultSecret) error {
+	key := client.ObjectKeyFromObject(vs)
+	latest := &v1beta1.VaultSecret{}
+	if err := r.Client.Get(ctx, key, latest); err != nil {
+		return err
+	}
+
+	return r.Client.Status().Patch(ctx, vs, client.MergeFrom(latest))
+}
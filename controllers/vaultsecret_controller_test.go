@@ -0,0 +1,243 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	// +kubebuilder:scaffold:imports
+)
+
+var _ = Describe("VaultSecretReconciler", func() {
+	const (
+		timeout  = time.Second * 10
+		interval = time.Second * 1
+	)
+
+	Context("VaultSecret", func() {
+		var (
+			namespace *corev1.Namespace
+			err       error
+		)
+
+		_, err = setupvaultContainer(context.TODO())
+		Expect(err).NotTo(HaveOccurred(), "failed to start vault container")
+
+		BeforeEach(func() {
+			namespace = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "vaultsecret-" + randStringRunes(5)},
+			}
+			err = k8sClient.Create(context.Background(), namespace)
+			Expect(err).NotTo(HaveOccurred(), "failed to create test namespace")
+
+			file, err := os.CreateTemp(os.TempDir(), "jwt")
+			Expect(err).NotTo(HaveOccurred(), "failed to create temp jwt file")
+			defer os.Remove(file.Name())
+		})
+
+		AfterEach(func() {
+			Eventually(func() error {
+				return k8sClient.Delete(context.Background(), namespace)
+			}, timeout, interval).Should(Succeed(), "failed to delete test namespace")
+		})
+
+		It("fails if vault can't be contacted", func() {
+			key := types.NamespacedName{
+				Name:      "vaultsecret-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			}
+			created := &infrav1beta1.VaultSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+				},
+				Spec: infrav1beta1.VaultSecretSpec{
+					VaultSpec: &infrav1beta1.VaultSpec{
+						Address: "https://does-not-exists",
+						Path:    "/source/not-found",
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), created)).Should(Succeed())
+
+			got := &infrav1beta1.VaultSecret{}
+			Eventually(func() bool {
+				_ = k8sClient.Get(context.Background(), key, got)
+				return len(got.Status.Conditions) == 1 &&
+					got.Status.Conditions[0].Reason == infrav1beta1.VaultConnectionFailedReason &&
+					got.Status.Conditions[0].Status == "False" &&
+					got.Status.Conditions[0].Type == infrav1beta1.BoundCondition
+			}, timeout, interval).Should(BeTrue())
+			Expect(got.Status.LastSyncTime).To(BeNil())
+		})
+	})
+})
+
+func TestMapSecretData(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		mapping     []infrav1beta1.FieldMapping
+		data        map[string]interface{}
+		expectData  map[string][]byte
+		expectError bool
+	}{
+		{
+			name: "map all fields without field mapping",
+			expectData: map[string][]byte{
+				"fruit":  []byte("banana"),
+				"amount": []byte("3"),
+				"tags":   []byte(`["yellow"]`),
+			},
+		},
+		{
+			name: "map and rename fields",
+			mapping: []infrav1beta1.FieldMapping{
+				{Name: "fruit", Rename: "FRUIT"},
+			},
+			expectData: map[string][]byte{
+				"FRUIT": []byte("banana"),
+			},
+		},
+		{
+			name: "skip fields with a null value",
+			mapping: []infrav1beta1.FieldMapping{
+				{Name: "fruit"},
+				{Name: "vegetable"},
+			},
+			data: map[string]interface{}{
+				"fruit":     "banana",
+				"vegetable": nil,
+			},
+			expectData: map[string][]byte{
+				"fruit": []byte("banana"),
+			},
+		},
+		{
+			name: "fail if field does not exist",
+			mapping: []infrav1beta1.FieldMapping{
+				{Name: "vegetable"},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.data == nil {
+				test.data = map[string]interface{}{
+					"fruit":  "banana",
+					"amount": json.Number("3"),
+					"tags":   []interface{}{"yellow"},
+				}
+			}
+
+			data, err := mapSecretData(test.mapping, test.data)
+
+			if test.expectError {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(data).To(Equal(test.expectData))
+		})
+	}
+}
+
+func TestReconcileSpans(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(provider)
+
+	scheme := runtime.NewScheme()
+	g.Expect(infrav1beta1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	vs := &infrav1beta1.VaultSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: infrav1beta1.VaultSecretSpec{
+			VaultSpec: &infrav1beta1.VaultSpec{
+				Address: "http://127.0.0.1:1",
+				Path:    "/secret/app",
+				Auth: infrav1beta1.VaultAuthSpec{
+					Type: "unsupported",
+				},
+			},
+		},
+	}
+
+	r := &VaultSecretReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(vs).Build(),
+		Log:      logr.Discard(),
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(10),
+	}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(vs)})
+	g.Expect(err).To(HaveOccurred())
+
+	// The failed login is traced below the reconcile of the VaultSecret
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(3))
+
+	g.Expect(spans[0].Name()).To(Equal("vault.setupAuth"))
+	g.Expect(spans[0].Parent().SpanID()).To(Equal(spans[1].SpanContext().SpanID()))
+	g.Expect(spans[0].Status().Code).To(Equal(codes.Error))
+
+	g.Expect(spans[1].Name()).To(Equal("vault.NewHandler"))
+	g.Expect(spans[1].Parent().SpanID()).To(Equal(spans[2].SpanContext().SpanID()))
+	g.Expect(spans[1].Attributes()).To(ContainElement(attribute.String("vault.path", "/secret/app")))
+	g.Expect(spans[1].Status().Code).To(Equal(codes.Error))
+
+	g.Expect(spans[2].Name()).To(Equal("VaultSecret.Reconcile"))
+	g.Expect(spans[2].Attributes()).To(ContainElements(
+		attribute.String("k8s.resource.kind", "VaultSecret"),
+		attribute.String("k8s.namespace.name", "default"),
+		attribute.String("k8s.resource.name", "app"),
+	))
+	g.Expect(spans[2].Status().Code).To(Equal(codes.Error))
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Operations which mutate vault
+const (
+	OperationWrite  = "write"
+	OperationPrune  = "prune"
+	OperationDelete = "delete"
+)
+
+// Record describes a single mutation of a vault path
+// Secret values are never part of a record, only hashes of them.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+
+	// Kind, Namespace and Name identify the resource which caused the mutation
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// Actor is the kubernetes field manager which most recently changed the resource or its secret.
+	// It is derived from the managed fields of the objects and is not necessarily the identity which caused the mutation,
+	// use the kubernetes audit log to attribute a change to a user.
+	Actor string `json:"actor,omitempty"`
+
+	Address string `json:"address"`
+	Path    string `json:"path"`
+
+	// Version is the kv version 2 secret version after the mutation
+	Version int `json:"version,omitempty"`
+
+	Added   []string `json:"added,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Skipped []string `json:"skipped,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+
+	// PreviousHash and Hash are the keyed hashes of the written fields and their values before and after a write.
+	// The written fields are the ones holding the value of the resource after the write.
+	// They are keyed like the dataHash in the status which may cover additional drifted fields which were not re-applied.
+	PreviousHash string `json:"previousHash,omitempty"`
+	Hash         string `json:"hash,omitempty"`
+}
+
+// Sink receives audit records
+type Sink interface {
+	Emit(ctx context.Context, record Record) error
+}
+
+// JSONSink writes each record as a JSON line
+type JSONSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewJSONSink creates a sink writing to w
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{w: w}
+}
+
+// Open creates a sink writing to stdout if the target is "stdout", otherwise records get appended to the target file
+func Open(target string) (*JSONSink, error) {
+	if target == "stdout" {
+		return NewJSONSink(os.Stdout), nil
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONSink{w: f, closer: f}, nil
+}
+
+// Emit writes the record as a single line
+func (s *JSONSink) Emit(ctx context.Context, record Record) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the target file
+func (s *JSONSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+
+	return s.closer.Close()
+}
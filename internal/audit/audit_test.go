@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestJSONSink(t *testing.T) {
+	g := NewWithT(t)
+
+	var buf bytes.Buffer
+	sink := NewJSONSink(&buf)
+
+	for _, op := range []string{OperationWrite, OperationPrune} {
+		err := sink.Emit(context.Background(), Record{
+			Time:      time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC),
+			Operation: op,
+			Kind:      "VaultBinding",
+			Namespace: "default",
+			Name:      "app",
+			Address:   "https://vault:8200",
+			Path:      "secret/data/app",
+			Added:     []string{"password"},
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	g.Expect(lines).To(HaveLen(2))
+
+	var record map[string]interface{}
+	g.Expect(json.Unmarshal([]byte(lines[0]), &record)).To(Succeed())
+	g.Expect(record).To(Equal(map[string]interface{}{
+		"time":      "2023-04-01T00:00:00Z",
+		"operation": "write",
+		"kind":      "VaultBinding",
+		"namespace": "default",
+		"name":      "app",
+		"address":   "https://vault:8200",
+		"path":      "secret/data/app",
+		"added":     []interface{}{"password"},
+	}))
+}
+
+func TestOpen(t *testing.T) {
+	g := NewWithT(t)
+
+	target := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < 2; i++ {
+		sink, err := Open(target)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(sink.Emit(context.Background(), Record{Operation: OperationDelete})).To(Succeed())
+		g.Expect(sink.Close()).To(Succeed())
+	}
+
+	// Records are appended to an existing file
+	b, err := os.ReadFile(target)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(strings.Count(string(b), "\n")).To(Equal(2))
+}
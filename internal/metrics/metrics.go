@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Vault operations
+const (
+	OperationRead   = "read"
+	OperationWrite  = "write"
+	OperationDelete = "delete"
+	OperationList   = "list"
+	OperationLogin  = "login"
+)
+
+// Outcomes of vault requests and written fields
+const (
+	OutcomeSuccess   = "success"
+	OutcomeError     = "error"
+	OutcomeWritten   = "written"
+	OutcomeUnchanged = "unchanged"
+	OutcomeSkipped   = "skipped"
+)
+
+const namespace = "k8svault"
+
+var (
+	vaultRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "vault_requests_total",
+			Help:      "Total number of vault requests by vault address, operation and outcome.",
+		},
+		[]string{"address", "operation", "outcome"},
+	)
+
+	vaultRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "vault_request_duration_seconds",
+			Help:      "Duration of vault requests by vault address, operation and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"address", "operation", "outcome"},
+	)
+
+	fieldsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "fields_total",
+			Help:      "Total number of fields handled by vault writes by vault address and outcome (written, unchanged or skipped).",
+		},
+		[]string{"address", "outcome"},
+	)
+
+	resourceBound = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "resource_bound",
+			Help:      "Status of the Bound condition of a resource, 1 if bound and 0 otherwise.",
+		},
+		[]string{"kind", "namespace", "name"},
+	)
+
+	resourceLastSync = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "resource_last_sync_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful sync of a resource.",
+		},
+		[]string{"kind", "namespace", "name"},
+	)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		vaultRequestsTotal,
+		vaultRequestDuration,
+		fieldsTotal,
+		resourceBound,
+		resourceLastSync,
+	)
+}
+
+// ObserveVaultRequest records a vault request and its duration
+func ObserveVaultRequest(address, operation string, duration time.Duration, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+
+	vaultRequestsTotal.WithLabelValues(address, operation, outcome).Inc()
+	vaultRequestDuration.WithLabelValues(address, operation, outcome).Observe(duration.Seconds())
+}
+
+// AddFields records the number of fields handled by a vault write with the given outcome
+func AddFields(address, outcome string, count int) {
+	if count > 0 {
+		fieldsTotal.WithLabelValues(address, outcome).Add(float64(count))
+	}
+}
+
+// RecordResource records the Bound condition status and the last successful sync of a resource
+// The last sync is not updated if it is nil.
+func RecordResource(kind, namespace, name string, bound bool, lastSync *time.Time) {
+	value := 0.0
+	if bound {
+		value = 1
+	}
+
+	resourceBound.WithLabelValues(kind, namespace, name).Set(value)
+
+	if lastSync != nil {
+		resourceLastSync.WithLabelValues(kind, namespace, name).Set(float64(lastSync.Unix()))
+	}
+}
+
+// DeleteResource removes the metrics of a deleted resource
+func DeleteResource(kind, namespace, name string) {
+	resourceBound.DeleteLabelValues(kind, namespace, name)
+	resourceLastSync.DeleteLabelValues(kind, namespace, name)
+}
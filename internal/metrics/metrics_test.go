@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveVaultRequest(t *testing.T) {
+	g := NewWithT(t)
+
+	ObserveVaultRequest("https://vault:8200", OperationRead, time.Millisecond, nil)
+	ObserveVaultRequest("https://vault:8200", OperationRead, time.Millisecond, errors.New("read fails"))
+	ObserveVaultRequest("https://vault:8200", OperationRead, time.Millisecond, errors.New("read fails"))
+
+	g.Expect(testutil.ToFloat64(vaultRequestsTotal.WithLabelValues("https://vault:8200", OperationRead, OutcomeSuccess))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(vaultRequestsTotal.WithLabelValues("https://vault:8200", OperationRead, OutcomeError))).To(Equal(2.0))
+}
+
+func TestAddFields(t *testing.T) {
+	g := NewWithT(t)
+
+	AddFields("https://fields:8200", OutcomeWritten, 2)
+	AddFields("https://fields:8200", OutcomeSkipped, 0)
+
+	g.Expect(testutil.ToFloat64(fieldsTotal.WithLabelValues("https://fields:8200", OutcomeWritten))).To(Equal(2.0))
+	g.Expect(testutil.CollectAndCount(fieldsTotal)).To(Equal(1))
+}
+
+func TestRecordResource(t *testing.T) {
+	g := NewWithT(t)
+
+	sync := time.Unix(1700000000, 0)
+	RecordResource("VaultBinding", "default", "app", true, &sync)
+	g.Expect(testutil.ToFloat64(resourceBound.WithLabelValues("VaultBinding", "default", "app"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(resourceLastSync.WithLabelValues("VaultBinding", "default", "app"))).To(Equal(1700000000.0))
+
+	// A failed sync keeps the last successful sync time
+	RecordResource("VaultBinding", "default", "app", false, nil)
+	g.Expect(testutil.ToFloat64(resourceBound.WithLabelValues("VaultBinding", "default", "app"))).To(Equal(0.0))
+	g.Expect(testutil.ToFloat64(resourceLastSync.WithLabelValues("VaultBinding", "default", "app"))).To(Equal(1700000000.0))
+
+	DeleteResource("VaultBinding", "default", "app")
+	g.Expect(testutil.CollectAndCount(resourceBound)).To(Equal(0))
+	g.Expect(testutil.CollectAndCount(resourceLastSync)).To(Equal(0))
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/DoodleScheduling/k8svault-controller"
+	serviceName         = "k8svault-controller"
+
+	// The standard OTLP exporter endpoint envs, the exporter reads them if no endpoint is configured
+	envEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	envTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+)
+
+// Span attributes, secret values must never be added to spans
+const (
+	AttributeKind         = attribute.Key("k8s.resource.kind")
+	AttributeNamespace    = attribute.Key("k8s.namespace.name")
+	AttributeName         = attribute.Key("k8s.resource.name")
+	AttributeVaultAddress = attribute.Key("vault.address")
+	AttributeVaultPath    = attribute.Key("vault.path")
+	AttributeAuthType     = attribute.Key("vault.auth.type")
+)
+
+// Options configures the OTLP trace exporter
+type Options struct {
+	// Endpoint is the host and port of the OTLP http receiver.
+	// If empty the standard OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT env is used,
+	// tracing is disabled if neither is set.
+	Endpoint string
+
+	// Insecure disables TLS towards the receiver
+	Insecure bool
+
+	// SampleRatio is the ratio of sampled traces, a parent span decision is always respected
+	SampleRatio float64
+}
+
+// Setup registers a global tracer provider which exports spans to an OTLP receiver.
+// The returned function flushes and stops the exporter.
+// If no endpoint is configured spans are not recorded.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	var exporterOpts []otlptracehttp.Option
+	switch {
+	case opts.Endpoint != "":
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+	case os.Getenv(envTracesEndpoint) == "" && os.Getenv(envEndpoint) == "":
+		return func(context.Context) error { return nil }, nil
+	}
+
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span using the global tracer provider
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends a span and marks it as failed if there is an error
+func End(span trace.Span, err error) {
+	RecordError(span, err)
+	span.End()
+}
+
+// RecordError marks a span as failed if there is an error
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// ResourceAttributes are the span attributes identifying a kubernetes resource
+func ResourceAttributes(kind, namespace, name string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttributeKind.String(kind),
+		AttributeNamespace.String(namespace),
+		AttributeName.String(name),
+	}
+}
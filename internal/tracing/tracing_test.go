@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv(envEndpoint, "")
+	t.Setenv(envTracesEndpoint, "")
+	before := otel.GetTracerProvider()
+
+	shutdown, err := Setup(context.Background(), Options{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(otel.GetTracerProvider()).To(BeIdenticalTo(before))
+
+	_, span := Start(context.Background(), "test")
+	g.Expect(span.IsRecording()).To(BeFalse())
+	g.Expect(span.SpanContext().IsValid()).To(BeFalse())
+	span.End()
+
+	g.Expect(shutdown(context.Background())).To(Succeed())
+}
+
+func TestSetupWithEndpoint(t *testing.T) {
+	g := NewWithT(t)
+
+	var exports int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			atomic.AddInt32(&exports, 1)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	shutdown, err := Setup(context.Background(), Options{
+		Endpoint:    strings.TrimPrefix(receiver.URL, "http://"),
+		Insecure:    true,
+		SampleRatio: 1,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(otel.GetTracerProvider()).To(BeAssignableToTypeOf(&sdktrace.TracerProvider{}))
+
+	_, span := Start(context.Background(), "test")
+	g.Expect(span.IsRecording()).To(BeTrue())
+	g.Expect(span.SpanContext().IsValid()).To(BeTrue())
+	span.End()
+
+	// Spans are flushed to the receiver on shutdown
+	g.Expect(shutdown(context.Background())).To(Succeed())
+	g.Expect(atomic.LoadInt32(&exports)).To(BeNumerically(">", 0))
+}
+
+func TestSetupWithEndpointEnv(t *testing.T) {
+	g := NewWithT(t)
+
+	var exports int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			atomic.AddInt32(&exports, 1)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	// The env holds an URL, the http scheme disables TLS
+	t.Setenv(envEndpoint, receiver.URL)
+	shutdown, err := Setup(context.Background(), Options{
+		SampleRatio: 1,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(otel.GetTracerProvider()).To(BeAssignableToTypeOf(&sdktrace.TracerProvider{}))
+
+	_, span := Start(context.Background(), "test")
+	g.Expect(span.IsRecording()).To(BeTrue())
+	span.End()
+
+	g.Expect(shutdown(context.Background())).To(Succeed())
+	g.Expect(atomic.LoadInt32(&exports)).To(BeNumerically(">", 0))
+}
+
+func TestSpans(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(provider)
+
+	ctx, parent := Start(context.Background(), "VaultBinding.Reconcile", ResourceAttributes("VaultBinding", "default", "app")...)
+	_, child := Start(ctx, "vault.Read", AttributeVaultPath.String("/secret/app"))
+	End(child, errors.New("permission denied"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(2))
+
+	g.Expect(spans[0].Name()).To(Equal("vault.Read"))
+	g.Expect(spans[0].Parent().SpanID()).To(Equal(spans[1].SpanContext().SpanID()))
+	g.Expect(spans[0].Attributes()).To(ContainElement(AttributeVaultPath.String("/secret/app")))
+	g.Expect(spans[0].Status().Code).To(Equal(codes.Error))
+	g.Expect(spans[0].Status().Description).To(Equal("permission denied"))
+	g.Expect(spans[0].Events()).To(HaveLen(1))
+
+	g.Expect(spans[1].Name()).To(Equal("VaultBinding.Reconcile"))
+	g.Expect(spans[1].Attributes()).To(ConsistOf(
+		AttributeKind.String("VaultBinding"),
+		AttributeNamespace.String("default"),
+		AttributeName.String("app"),
+	))
+	g.Expect(spans[1].Status().Code).To(Equal(codes.Unset))
+}
@@ -12,6 +12,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/DoodleScheduling/k8svault-controller/internal/metrics"
+	"github.com/DoodleScheduling/k8svault-controller/internal/tracing"
 )
 
 // AuthMethod is the interface that auto-auth methods implement for the agent
@@ -22,6 +30,12 @@ type AuthMethod interface {
 	Authenticate(context.Context) (string, http.Header, map[string]interface{}, error)
 }
 
+// TokenMethod is implemented by auth methods which provide a vault token directly
+// instead of sending a login request.
+type TokenMethod interface {
+	Token(context.Context) (string, error)
+}
+
 type AuthConfig struct {
 	MountPath string
 	Config    map[string]interface{}
@@ -30,40 +44,146 @@ type AuthConfig struct {
 // AuthHandler is responsible for keeping a token alive and renewed and passing
 // new tokens to the sink server
 type AuthHandler struct {
+	reader      Reader
 	writer      Writer
 	tokenWriter TokenWriter
+	watcher     TokenWatcher
+	logger      logr.Logger
+	address     string
+
+	mu            sync.Mutex
+	method        AuthMethod
+	leaseDuration time.Duration
+	renewable     bool
+	issued        time.Time
+	stop          chan struct{}
 }
 
 type TokenWriter interface {
 	SetToken(token string)
 }
 
+// tokenWriters passes a token to multiple writers
+type tokenWriters []TokenWriter
+
+func (w tokenWriters) SetToken(token string) {
+	for _, writer := range w {
+		writer.SetToken(token)
+	}
+}
+
+// TokenWatcher creates lifetime watchers which renew a token in the background
+type TokenWatcher interface {
+	NewLifetimeWatcher(i *vaultapi.LifetimeWatcherInput) (*vaultapi.LifetimeWatcher, error)
+}
+
 type AuthHandlerConfig struct {
+	Reader      Reader
 	Writer      Writer
 	TokenWriter TokenWriter
+
+	// Watcher is optional, if set tokens get renewed in the background
+	Watcher TokenWatcher
+	Logger  logr.Logger
+
+	// Address of the vault server used to label login metrics
+	Address string
 }
 
 func NewAuthHandler(opts AuthHandlerConfig) *AuthHandler {
 	ah := &AuthHandler{
+		reader:      opts.Reader,
 		writer:      opts.Writer,
 		tokenWriter: opts.TokenWriter,
+		watcher:     opts.Watcher,
+		logger:      opts.Logger,
+		address:     opts.Address,
+	}
+
+	if ah.logger.GetSink() == nil {
+		ah.logger = logr.Discard()
 	}
 
 	return ah
 }
 
+// Authenticate logs in using the given auth method and keeps track of the token lease
 func (ah *AuthHandler) Authenticate(ctx context.Context, am AuthMethod) error {
 	if am == nil {
 		return errors.New("auth handler: nil auth method")
 	}
 
+	ah.mu.Lock()
+	defer ah.mu.Unlock()
+
+	return ah.authenticate(ctx, am)
+}
+
+// Reauthenticate logs in again using the auth method of the last authentication
+func (ah *AuthHandler) Reauthenticate(ctx context.Context) error {
+	ah.mu.Lock()
+	defer ah.mu.Unlock()
+
+	if ah.method == nil {
+		return errors.New("auth handler: not authenticated")
+	}
+
+	return ah.authenticate(ctx, ah.method)
+}
+
+// Renew renews the token if two thirds of its lease duration have passed.
+// If the token is not renewable or the renewal fails a new login is done.
+// With a background watcher the lease is usually renewed already and this is a no-op.
+func (ah *AuthHandler) Renew(ctx context.Context) error {
+	ah.mu.Lock()
+	defer ah.mu.Unlock()
+
+	if ah.method == nil {
+		return errors.New("auth handler: not authenticated")
+	}
+
+	// Tokens without a lease do not expire
+	if ah.leaseDuration == 0 || time.Since(ah.issued) < ah.leaseDuration*2/3 {
+		return nil
+	}
+
+	if ah.renewable {
+		secret, err := ah.writer.WriteWithContext(ctx, "auth/token/renew-self", nil)
+		if err == nil && secret != nil && secret.Auth != nil && secret.Auth.LeaseDuration > 0 {
+			ah.setLease(time.Duration(secret.Auth.LeaseDuration)*time.Second, secret.Auth.Renewable)
+			return nil
+		}
+	}
+
+	return ah.authenticate(ctx, ah.method)
+}
+
+func (ah *AuthHandler) authenticate(ctx context.Context, am AuthMethod) error {
+	ctx, span := tracing.Start(ctx, "vault.login", tracing.AttributeVaultAddress.String(ah.address))
+	start := time.Now()
+	err := ah.login(ctx, am)
+	metrics.ObserveVaultRequest(ah.address, metrics.OperationLogin, time.Since(start), err)
+	tracing.End(span, err)
+	return err
+}
+
+func (ah *AuthHandler) login(ctx context.Context, am AuthMethod) error {
+	if tm, ok := am.(TokenMethod); ok {
+		if err := ah.authenticateToken(ctx, tm); err != nil {
+			return err
+		}
+
+		ah.method = am
+		return nil
+	}
+
 	path, _, data, err := am.Authenticate(ctx)
 
 	if err != nil {
 		return fmt.Errorf("error getting path or data from method: %w", err)
 	}
 
-	secret, err := ah.writer.Write(path, data)
+	secret, err := ah.writer.WriteWithContext(ctx, path, data)
 
 	if err != nil {
 		return fmt.Errorf("login request failed: %w", err)
@@ -78,5 +198,156 @@ func (ah *AuthHandler) Authenticate(ctx context.Context, am AuthMethod) error {
 	}
 
 	ah.tokenWriter.SetToken(secret.Auth.ClientToken)
+	ah.method = am
+	ah.setLease(time.Duration(secret.Auth.LeaseDuration)*time.Second, secret.Auth.Renewable)
+	ah.startWatcher(secret.Auth.ClientToken)
+	return nil
+}
+
+// authenticateToken uses the token provided by the method and validates it using a token self lookup
+func (ah *AuthHandler) authenticateToken(ctx context.Context, tm TokenMethod) error {
+	token, err := tm.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting token from method: %w", err)
+	}
+
+	if token == "" {
+		return errors.New("token method returned empty token")
+	}
+
+	if ah.reader == nil {
+		return errors.New("auth handler: no reader available to validate token")
+	}
+
+	ah.tokenWriter.SetToken(token)
+
+	secret, err := ah.reader.ReadWithContext(ctx, "auth/token/lookup-self")
+	if err != nil {
+		return fmt.Errorf("token lookup failed: %w", err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return errors.New("token lookup returned no token information")
+	}
+
+	ttl, _ := secret.TokenTTL()
+	renewable, _ := secret.TokenIsRenewable()
+	ah.setLease(ttl, renewable)
+	ah.startWatcher(token)
+
+	return nil
+}
+
+func (ah *AuthHandler) setLease(leaseDuration time.Duration, renewable bool) {
+	ah.leaseDuration = leaseDuration
+	ah.renewable = renewable
+	ah.issued = time.Now()
+}
+
+// Close stops the background renewal and revokes the token.
+// Static tokens provided by a TokenMethod are not revoked.
+func (ah *AuthHandler) Close() error {
+	ah.mu.Lock()
+	defer ah.mu.Unlock()
+
+	ah.stopWatcher()
+
+	if ah.method == nil {
+		return nil
+	}
+
+	_, isTokenMethod := ah.method.(TokenMethod)
+	ah.method = nil
+
+	if isTokenMethod {
+		return nil
+	}
+
+	if _, err := ah.writer.WriteWithContext(context.Background(), "auth/token/revoke-self", nil); err != nil {
+		return fmt.Errorf("token revocation failed: %w", err)
+	}
+
 	return nil
 }
+
+// startWatcher renews the token in the background until the renewal fails or the max ttl is reached.
+// Afterwards a new login is done which starts a new watcher.
+func (ah *AuthHandler) startWatcher(token string) {
+	ah.stopWatcher()
+
+	if ah.watcher == nil || !ah.renewable || ah.leaseDuration == 0 {
+		return
+	}
+
+	w, err := ah.watcher.NewLifetimeWatcher(&vaultapi.LifetimeWatcherInput{
+		Secret: &vaultapi.Secret{
+			Auth: &vaultapi.SecretAuth{
+				ClientToken:   token,
+				LeaseDuration: int(ah.leaseDuration.Seconds()),
+				Renewable:     ah.renewable,
+			},
+		},
+		// Stop on the first renewal error, a new login is done instead
+		RenewBehavior: vaultapi.RenewBehaviorErrorOnErrors,
+	})
+
+	if err != nil {
+		ah.logger.Error(err, "failed to start token renewal")
+		return
+	}
+
+	stop := make(chan struct{})
+	ah.stop = stop
+
+	go ah.watch(w, stop)
+}
+
+func (ah *AuthHandler) stopWatcher() {
+	if ah.stop != nil {
+		close(ah.stop)
+		ah.stop = nil
+	}
+}
+
+func (ah *AuthHandler) watch(w *vaultapi.LifetimeWatcher, stop chan struct{}) {
+	go w.Start()
+	defer w.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case renewal := <-w.RenewCh():
+			if renewal == nil || renewal.Secret == nil || renewal.Secret.Auth == nil {
+				continue
+			}
+
+			ah.mu.Lock()
+			ah.setLease(time.Duration(renewal.Secret.Auth.LeaseDuration)*time.Second, renewal.Secret.Auth.Renewable)
+			ah.mu.Unlock()
+		case err := <-w.DoneCh():
+			ah.mu.Lock()
+			defer ah.mu.Unlock()
+
+			// The watcher was stopped while waiting for the lock
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			if err != nil {
+				ah.logger.Info("token renewal failed, login again", "error", err.Error())
+			} else {
+				ah.logger.Info("token reached its max ttl, login again")
+			}
+
+			ah.stop = nil
+			if err := ah.authenticate(context.Background(), ah.method); err != nil {
+				ah.logger.Error(err, "login after token renewal failed")
+			}
+
+			return
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+func init() {
+	registry.MustRegister("approle", authAppRole)
+}
+
+const (
+	defaultAppRoleMountPath   = "/auth/approle"
+	defaultAppRoleRoleIDKey   = "role_id"
+	defaultAppRoleSecretIDKey = "secret_id"
+)
+
+type appRoleMethod struct {
+	mountPath string
+
+	roleID   *secretKeyReader
+	secretID *secretKeyReader
+}
+
+// Wrapper around vault approle auth, role_id and secret_id are read from a kubernetes secret
+func authAppRole(config *v1beta1.VaultAuthSpec, opts HandlerOptions) (AuthMethod, error) {
+	if config.AppRole == nil || config.AppRole.SecretRef.Name == "" {
+		return nil, errors.New("approle authentication requires a secret reference")
+	}
+
+	roleIDKey := config.AppRole.RoleIDKey
+	if roleIDKey == "" {
+		roleIDKey = defaultAppRoleRoleIDKey
+	}
+
+	secretIDKey := config.AppRole.SecretIDKey
+	if secretIDKey == "" {
+		secretIDKey = defaultAppRoleSecretIDKey
+	}
+
+	return &appRoleMethod{
+		mountPath: authMountPath(config.MountPath, defaultAppRoleMountPath),
+		roleID: &secretKeyReader{
+			client:    opts.Client,
+			namespace: opts.Namespace,
+			name:      config.AppRole.SecretRef.Name,
+			key:       roleIDKey,
+		},
+		secretID: &secretKeyReader{
+			client:    opts.Client,
+			namespace: opts.Namespace,
+			name:      config.AppRole.SecretRef.Name,
+			key:       secretIDKey,
+		},
+	}, nil
+}
+
+func (a *appRoleMethod) Authenticate(ctx context.Context) (string, http.Header, map[string]interface{}, error) {
+	roleID, err := a.roleID.read(ctx)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error reading role_id with AppRole Auth: %w", err)
+	}
+
+	secretID, err := a.secretID.read(ctx)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error reading secret_id with AppRole Auth: %w", err)
+	}
+
+	return fmt.Sprintf("%s/login", a.mountPath), nil, map[string]interface{}{
+		"role_id":   roleID,
+		"secret_id": secretID,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+func TestAuthAppRole(t *testing.T) {
+	g := NewWithT(t)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "approle",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"role_id":   []byte("strawberry"),
+			"secret_id": []byte("blueberry"),
+			"custom_id": []byte("raspberry"),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		config      *v1beta1.VaultAuthSpec
+		expectPath  string
+		expectData  map[string]interface{}
+		expectError error
+	}{
+		{
+			name:        "fails if no secret is referenced",
+			config:      &v1beta1.VaultAuthSpec{},
+			expectError: errors.New("approle authentication requires a secret reference"),
+		},
+		{
+			name: "fails if secret does not exist",
+			config: &v1beta1.VaultAuthSpec{
+				AppRole: &v1beta1.VaultAppRoleSpec{
+					SecretRef: corev1.LocalObjectReference{Name: "does-not-exist"},
+				},
+			},
+			expectError: errors.New(`error reading role_id with AppRole Auth: failed to get secret default/does-not-exist: secrets "does-not-exist" not found`),
+		},
+		{
+			name: "fails if secret key does not exist",
+			config: &v1beta1.VaultAuthSpec{
+				AppRole: &v1beta1.VaultAppRoleSpec{
+					SecretRef:   corev1.LocalObjectReference{Name: "approle"},
+					SecretIDKey: "does-not-exist",
+				},
+			},
+			expectError: errors.New("error reading secret_id with AppRole Auth: secret default/approle has no key does-not-exist"),
+		},
+		{
+			name: "login with default mount path and keys",
+			config: &v1beta1.VaultAuthSpec{
+				AppRole: &v1beta1.VaultAppRoleSpec{
+					SecretRef: corev1.LocalObjectReference{Name: "approle"},
+				},
+			},
+			expectPath: "/auth/approle/login",
+			expectData: map[string]interface{}{
+				"role_id":   "strawberry",
+				"secret_id": "blueberry",
+			},
+		},
+		{
+			name: "login with custom mount path and keys",
+			config: &v1beta1.VaultAuthSpec{
+				MountPath: "/auth/berries",
+				AppRole: &v1beta1.VaultAppRoleSpec{
+					SecretRef:   corev1.LocalObjectReference{Name: "approle"},
+					SecretIDKey: "custom_id",
+				},
+			},
+			expectPath: "/auth/berries/login",
+			expectData: map[string]interface{}{
+				"role_id":   "strawberry",
+				"secret_id": "raspberry",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method, err := authAppRole(test.config, HandlerOptions{
+				Client:    fake.NewClientBuilder().WithObjects(secret).Build(),
+				Namespace: "default",
+			})
+
+			if err == nil {
+				var path string
+				var data map[string]interface{}
+				path, _, data, err = method.Authenticate(context.TODO())
+				if err == nil {
+					g.Expect(path).To(Equal(test.expectPath))
+					g.Expect(data).To(Equal(test.expectData))
+				}
+			}
+
+			if test.expectError == nil {
+				g.Expect(err).NotTo(HaveOccurred(), "error occurd during approle auth but should not")
+			} else {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(Equal(test.expectError.Error()))
+			}
+		})
+	}
+}
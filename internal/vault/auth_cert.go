@@ -0,0 +1,39 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+func init() {
+	registry.MustRegister("cert", authCert)
+}
+
+const defaultCertMountPath = "/auth/cert"
+
+type certMethod struct {
+	mountPath string
+
+	// name is the optional certificate role name, vault tries all roles if empty
+	name string
+}
+
+// Wrapper around vault tls certificate auth, the client certificate is taken from the tls configuration
+func authCert(config *v1beta1.VaultAuthSpec, opts HandlerOptions) (AuthMethod, error) {
+	return &certMethod{
+		mountPath: authMountPath(config.MountPath, defaultCertMountPath),
+		name:      config.Role,
+	}, nil
+}
+
+func (c *certMethod) Authenticate(ctx context.Context) (string, http.Header, map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if c.name != "" {
+		data["name"] = c.name
+	}
+
+	return fmt.Sprintf("%s/login", c.mountPath), nil, data, nil
+}
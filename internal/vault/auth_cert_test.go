@@ -0,0 +1,51 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+func TestAuthCert(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name       string
+		config     *v1beta1.VaultAuthSpec
+		expectPath string
+		expectData map[string]interface{}
+	}{
+		{
+			name:       "login with default mount path and without role",
+			config:     &v1beta1.VaultAuthSpec{},
+			expectPath: "/auth/cert/login",
+			expectData: map[string]interface{}{},
+		},
+		{
+			name: "login with custom mount path and role",
+			config: &v1beta1.VaultAuthSpec{
+				MountPath: "/auth/berries",
+				Role:      "blueberry",
+			},
+			expectPath: "/auth/berries/login",
+			expectData: map[string]interface{}{
+				"name": "blueberry",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method, err := authCert(test.config, HandlerOptions{})
+			g.Expect(err).NotTo(HaveOccurred(), "error occurd during initialize cert auth but should not")
+
+			path, _, data, err := method.Authenticate(context.TODO())
+			g.Expect(err).NotTo(HaveOccurred(), "error occurd during cert auth but should not")
+			g.Expect(path).To(Equal(test.expectPath))
+			g.Expect(data).To(Equal(test.expectData))
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+func init() {
+	registry.MustRegister("jwt", authJWT)
+}
+
+const (
+	defaultJWTMountPath         = "/auth/jwt"
+	defaultJWTExpirationSeconds = int64(600)
+)
+
+type jwtMethod struct {
+	mountPath string
+
+	// role is optional, vault uses the default role of the mount if empty
+	role string
+
+	// tokenPath is the service account token file, only used if no service account is set
+	tokenPath string
+
+	client             client.Client
+	namespace          string
+	serviceAccountName string
+	audiences          []string
+	expirationSeconds  int64
+}
+
+// Wrapper around vault jwt auth using kubernetes service account tokens
+func authJWT(config *v1beta1.VaultAuthSpec, opts HandlerOptions) (AuthMethod, error) {
+	m := &jwtMethod{
+		mountPath:         authMountPath(config.MountPath, defaultJWTMountPath),
+		role:              config.Role,
+		tokenPath:         serviceAccountFile,
+		client:            opts.Client,
+		namespace:         opts.Namespace,
+		expirationSeconds: defaultJWTExpirationSeconds,
+	}
+
+	if m.role == "" {
+		m.role = os.Getenv("VAULT_ROLE")
+	}
+
+	if config.JWT == nil {
+		return m, nil
+	}
+
+	if config.JWT.TokenPath != "" && config.JWT.ServiceAccountName != "" {
+		return nil, errors.New("jwt authentication accepts either a token path or a service account name")
+	}
+
+	if config.JWT.TokenPath != "" {
+		m.tokenPath = config.JWT.TokenPath
+	}
+
+	m.serviceAccountName = config.JWT.ServiceAccountName
+	if m.serviceAccountName != "" {
+		audiences, err := tokenRequestAudiences(config.JWT.Audiences, opts.TokenRequestAudiences)
+		if err != nil {
+			return nil, err
+		}
+
+		m.audiences = audiences
+	}
+
+	if config.JWT.ExpirationSeconds != nil {
+		m.expirationSeconds = *config.JWT.ExpirationSeconds
+	}
+
+	return m, nil
+}
+
+// tokenRequestAudiences returns the audiences a service account token is requested for.
+// Only audiences allowed by the controller are accepted, by default the token is issued for all of them.
+// A token without an audience or for the kubernetes api server would be valid against the api server
+// and must never be sent to a vault address controlled by the resource.
+func tokenRequestAudiences(requested, allowed []string) ([]string, error) {
+	if len(allowed) == 0 {
+		return nil, errors.New("requesting service account tokens is disabled, the controller must be started with --token-request-audiences")
+	}
+
+	if len(requested) == 0 {
+		requested = allowed
+	}
+
+	for _, audience := range requested {
+		if !containsString(allowed, audience) {
+			return nil, fmt.Errorf("service account token audience %s is not allowed", audience)
+		}
+
+		if isAPIServerAudience(audience) {
+			return nil, fmt.Errorf("service account token audience %s is the kubernetes api server", audience)
+		}
+	}
+
+	return requested, nil
+}
+
+// isAPIServerAudience returns true for the default audiences of the kubernetes api server
+func isAPIServerAudience(audience string) bool {
+	audience = strings.TrimSuffix(strings.TrimPrefix(audience, "https://"), "/")
+	return audience == "" || audience == "kubernetes" || strings.HasPrefix(audience, "kubernetes.default.svc")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (j *jwtMethod) Authenticate(ctx context.Context) (string, http.Header, map[string]interface{}, error) {
+	var jwtString string
+	var err error
+
+	if j.serviceAccountName != "" {
+		jwtString, err = j.requestJWT(ctx)
+	} else {
+		jwtString, err = j.readJWT()
+	}
+
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error reading JWT with JWT Auth: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"jwt": jwtString,
+	}
+
+	if j.role != "" {
+		data["role"] = j.role
+	}
+
+	return fmt.Sprintf("%s/login", j.mountPath), nil, data, nil
+}
+
+// readJWT reads the service account token from a projected token file
+func (j *jwtMethod) readJWT() (string, error) {
+	b, err := os.ReadFile(j.tokenPath)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// requestJWT issues a new service account token using the kubernetes TokenRequest API
+func (j *jwtMethod) requestJWT(ctx context.Context) (string, error) {
+	if j.client == nil {
+		return "", errors.New("no kubernetes client available to request service account token")
+	}
+
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      j.serviceAccountName,
+			Namespace: j.namespace,
+		},
+	}
+
+	tr := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         j.audiences,
+			ExpirationSeconds: &j.expirationSeconds,
+		},
+	}
+
+	if err := j.client.SubResource("token").Create(ctx, sa, tr); err != nil {
+		return "", fmt.Errorf("failed to request token for service account %s/%s: %w", j.namespace, j.serviceAccountName, err)
+	}
+
+	if tr.Status.Token == "" {
+		return "", fmt.Errorf("token request for service account %s/%s returned empty token", j.namespace, j.serviceAccountName)
+	}
+
+	return tr.Status.Token, nil
+}
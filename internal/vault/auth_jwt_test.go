@@ -0,0 +1,204 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+type testTokenRequestClient struct {
+	client.Client
+	token string
+	err   error
+	obj   client.Object
+	req   *authenticationv1.TokenRequest
+}
+
+func (c *testTokenRequestClient) SubResource(subResource string) client.SubResourceClient {
+	return &testTokenSubResourceClient{c}
+}
+
+type testTokenSubResourceClient struct {
+	c *testTokenRequestClient
+}
+
+func (c *testTokenSubResourceClient) Get(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+	return errors.New("not implemented")
+}
+
+func (c *testTokenSubResourceClient) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	c.c.obj = obj
+	c.c.req = subResource.(*authenticationv1.TokenRequest)
+	c.c.req.Status.Token = c.c.token
+	return c.c.err
+}
+
+func (c *testTokenSubResourceClient) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	return errors.New("not implemented")
+}
+
+func (c *testTokenSubResourceClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	return errors.New("not implemented")
+}
+
+func TestAuthJWT(t *testing.T) {
+	g := NewWithT(t)
+
+	file, err := os.CreateTemp(os.TempDir(), "jwt")
+	g.Expect(err).NotTo(HaveOccurred(), "failed creating test jwt file")
+	defer os.Remove(file.Name())
+	_, _ = file.Write([]byte("strawberry"))
+
+	expiration := int64(3600)
+
+	tests := []struct {
+		name             string
+		config           *v1beta1.VaultAuthSpec
+		allowed          []string
+		client           *testTokenRequestClient
+		expectPath       string
+		expectData       map[string]interface{}
+		expectAudiences  []string
+		expectExpiration int64
+		expectError      error
+	}{
+		{
+			name: "fails if both token path and service account are set",
+			config: &v1beta1.VaultAuthSpec{
+				JWT: &v1beta1.VaultJWTSpec{
+					TokenPath:          file.Name(),
+					ServiceAccountName: "default",
+				},
+			},
+			expectError: errors.New("jwt authentication accepts either a token path or a service account name"),
+		},
+		{
+			name: "login with token file",
+			config: &v1beta1.VaultAuthSpec{
+				Role: "blueberry",
+				JWT: &v1beta1.VaultJWTSpec{
+					TokenPath: file.Name(),
+				},
+			},
+			expectPath: "/auth/jwt/login",
+			expectData: map[string]interface{}{
+				"jwt":  "strawberry",
+				"role": "blueberry",
+			},
+		},
+		{
+			name: "login with requested token without role and custom mount path",
+			config: &v1beta1.VaultAuthSpec{
+				MountPath: "/auth/oidc",
+				JWT: &v1beta1.VaultJWTSpec{
+					ServiceAccountName: "vault",
+					Audiences:          []string{"vault"},
+					ExpirationSeconds:  &expiration,
+				},
+			},
+			allowed: []string{"vault", "other"},
+			client: &testTokenRequestClient{
+				token: "raspberry",
+			},
+			expectPath: "/auth/oidc/login",
+			expectData: map[string]interface{}{
+				"jwt": "raspberry",
+			},
+			expectAudiences:  []string{"vault"},
+			expectExpiration: 3600,
+		},
+		{
+			name: "fails if token request fails",
+			config: &v1beta1.VaultAuthSpec{
+				JWT: &v1beta1.VaultJWTSpec{
+					ServiceAccountName: "vault",
+				},
+			},
+			allowed: []string{"vault"},
+			client: &testTokenRequestClient{
+				err: errors.New("forbidden"),
+			},
+			expectAudiences:  []string{"vault"},
+			expectExpiration: 600,
+			expectError:      errors.New("error reading JWT with JWT Auth: failed to request token for service account default/vault: forbidden"),
+		},
+		{
+			name: "fails if token requests are disabled",
+			config: &v1beta1.VaultAuthSpec{
+				JWT: &v1beta1.VaultJWTSpec{
+					ServiceAccountName: "vault",
+					Audiences:          []string{"vault"},
+				},
+			},
+			expectError: errors.New("requesting service account tokens is disabled, the controller must be started with --token-request-audiences"),
+		},
+		{
+			name: "fails if audience is not allowed",
+			config: &v1beta1.VaultAuthSpec{
+				JWT: &v1beta1.VaultJWTSpec{
+					ServiceAccountName: "vault",
+					Audiences:          []string{"https://kubernetes.default.svc.cluster.local"},
+				},
+			},
+			allowed:     []string{"vault"},
+			expectError: errors.New("service account token audience https://kubernetes.default.svc.cluster.local is not allowed"),
+		},
+		{
+			name: "fails if allowed audience is the api server",
+			config: &v1beta1.VaultAuthSpec{
+				JWT: &v1beta1.VaultJWTSpec{
+					ServiceAccountName: "vault",
+				},
+			},
+			allowed:     []string{"https://kubernetes.default.svc"},
+			expectError: errors.New("service account token audience https://kubernetes.default.svc is the kubernetes api server"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := HandlerOptions{
+				Namespace:             "default",
+				TokenRequestAudiences: test.allowed,
+			}
+
+			if test.client != nil {
+				test.client.Client = fake.NewClientBuilder().Build()
+				opts.Client = test.client
+			}
+
+			method, err := authJWT(test.config, opts)
+			if err == nil {
+				var path string
+				var data map[string]interface{}
+				path, _, data, err = method.Authenticate(context.TODO())
+				if err == nil {
+					g.Expect(path).To(Equal(test.expectPath))
+					g.Expect(data).To(Equal(test.expectData))
+				}
+			}
+
+			if test.expectError == nil {
+				g.Expect(err).NotTo(HaveOccurred(), "error occurd during jwt auth but should not")
+			} else {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(Equal(test.expectError.Error()))
+			}
+
+			if test.client != nil {
+				g.Expect(test.client.obj.GetName()).To(Equal(test.config.JWT.ServiceAccountName))
+				g.Expect(test.client.obj.GetNamespace()).To(Equal("default"))
+				g.Expect(test.client.req.Spec.Audiences).To(Equal(test.expectAudiences))
+				g.Expect(*test.client.req.Spec.ExpirationSeconds).To(Equal(test.expectExpiration))
+			}
+		})
+	}
+}
@@ -18,8 +18,9 @@ func init() {
 }
 
 const (
-	serviceAccountFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
-	DefaultAuthRole    = "k8svault-controller"
+	serviceAccountFile         = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	DefaultAuthRole            = "k8svault-controller"
+	defaultKubernetesMountPath = "/auth/kubernetes"
 )
 
 type kubernetesMethod struct {
@@ -36,7 +37,7 @@ type kubernetesMethod struct {
 }
 
 // Wrapper around vault kubernetes auth (taken from vault agent)
-func authKubernetes(config *v1beta1.VaultAuthSpec) (AuthMethod, error) {
+func authKubernetes(config *v1beta1.VaultAuthSpec, opts HandlerOptions) (AuthMethod, error) {
 	var role string
 
 	switch {
@@ -54,7 +55,7 @@ func authKubernetes(config *v1beta1.VaultAuthSpec) (AuthMethod, error) {
 	}
 
 	return NewKubernetesAuthMethod(&AuthConfig{
-		MountPath: "/auth/kubernetes",
+		MountPath: authMountPath(config.MountPath, defaultKubernetesMountPath),
 		Config: map[string]interface{}{
 			"role":       role,
 			"token_path": tokenPath,
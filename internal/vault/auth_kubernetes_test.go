@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
 )
 
 func TestNewKubernetesAuthMethod(t *testing.T) {
@@ -110,3 +112,23 @@ func TestAuthKubernetes(t *testing.T) {
 	g.Expect(config["jwt"]).To(Equal("strawberry"))
 	g.Expect(config["role"]).To(Equal("blueberry"))
 }
+
+func TestAuthKubernetesMountPath(t *testing.T) {
+	g := NewWithT(t)
+
+	file, err := os.CreateTemp(os.TempDir(), "jwt")
+	g.Expect(err).NotTo(HaveOccurred(), "failed creating test jwt file")
+	defer os.Remove(file.Name())
+	_, _ = file.Write([]byte("strawberry"))
+
+	handler, err := authKubernetes(&v1beta1.VaultAuthSpec{
+		MountPath: "auth/k8s-prod",
+		Role:      "blueberry",
+		TokenPath: file.Name(),
+	}, HandlerOptions{})
+
+	g.Expect(err).NotTo(HaveOccurred(), "error occurd during initialize kubernetes auth but should not")
+	path, _, _, err := handler.Authenticate(context.TODO())
+	g.Expect(err).NotTo(HaveOccurred(), "error reading kubernetes jwt")
+	g.Expect(path).To(Equal("/auth/k8s-prod/login"))
+}
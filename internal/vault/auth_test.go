@@ -3,8 +3,12 @@ package vault
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	. "github.com/onsi/gomega"
@@ -21,6 +25,16 @@ func (h *testAuthHandler) Authenticate(ctx context.Context) (string, http.Header
 	return h.path, h.header, h.data, h.err
 }
 
+type testTokenMethod struct {
+	testAuthHandler
+	token string
+	err   error
+}
+
+func (m *testTokenMethod) Token(ctx context.Context) (string, error) {
+	return m.token, m.err
+}
+
 type testTokenWriter struct {
 	token string
 }
@@ -156,3 +170,272 @@ func TestAuthenticate(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticateToken(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		readWriter  *mockReadWriter
+		method      *testTokenMethod
+		expectToken string
+		expectError error
+	}{
+		{
+			name: "fails if token method fails",
+			method: &testTokenMethod{
+				err: errors.New("token method failed"),
+			},
+			readWriter:  &mockReadWriter{},
+			expectError: errors.New("error getting token from method: token method failed"),
+		},
+		{
+			name:        "fails if token is empty",
+			method:      &testTokenMethod{},
+			readWriter:  &mockReadWriter{},
+			expectError: errors.New("token method returned empty token"),
+		},
+		{
+			name: "fails if token lookup fails",
+			method: &testTokenMethod{
+				token: "banana",
+			},
+			readWriter: &mockReadWriter{
+				readResult: testResult{
+					err: errors.New("permission denied"),
+				},
+			},
+			expectToken: "banana",
+			expectError: errors.New("token lookup failed: permission denied"),
+		},
+		{
+			name: "Set token if token lookup was successful",
+			method: &testTokenMethod{
+				token: "banana",
+			},
+			readWriter: &mockReadWriter{
+				readResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"id": "banana",
+						},
+					},
+				},
+			},
+			expectToken: "banana",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokenWriter := &testTokenWriter{}
+			handler := NewAuthHandler(AuthHandlerConfig{
+				Reader:      test.readWriter,
+				Writer:      test.readWriter,
+				TokenWriter: tokenWriter,
+			})
+
+			err := handler.Authenticate(context.TODO(), test.method)
+			if test.expectError == nil {
+				g.Expect(err).NotTo(HaveOccurred(), "token auth error occurd but should not")
+			} else {
+				g.Expect(err.Error()).To(Equal(test.expectError.Error()))
+			}
+
+			g.Expect(tokenWriter.token).To(Equal(test.expectToken))
+			g.Expect(test.readWriter.writtenPath).To(BeEmpty())
+		})
+	}
+}
+
+func TestRenew(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name          string
+		leaseDuration time.Duration
+		renewable     bool
+		issued        time.Duration
+		writeResults  []testResult
+		expectToken   string
+		expectPath    string
+		expectError   error
+	}{
+		{
+			name:          "no renewal if token has no lease",
+			leaseDuration: 0,
+			issued:        time.Hour,
+		},
+		{
+			name:          "no renewal if token lease is not close to expire",
+			leaseDuration: time.Hour,
+			renewable:     true,
+			issued:        time.Minute,
+		},
+		{
+			name:          "renew token if lease is close to expire",
+			leaseDuration: time.Hour,
+			renewable:     true,
+			issued:        50 * time.Minute,
+			writeResults: []testResult{
+				{secret: &api.Secret{Auth: &api.SecretAuth{ClientToken: "banana", LeaseDuration: 3600, Renewable: true}}},
+			},
+			expectPath: "auth/token/renew-self",
+		},
+		{
+			name:          "login again if token is not renewable",
+			leaseDuration: time.Hour,
+			renewable:     false,
+			issued:        50 * time.Minute,
+			writeResults: []testResult{
+				{secret: &api.Secret{Auth: &api.SecretAuth{ClientToken: "cherry", LeaseDuration: 3600}}},
+			},
+			expectToken: "cherry",
+			expectPath:  "/auth/dummy",
+		},
+		{
+			name:          "login again if renewal fails",
+			leaseDuration: time.Hour,
+			renewable:     true,
+			issued:        50 * time.Minute,
+			writeResults: []testResult{
+				{err: errors.New("renewal failed")},
+				{secret: &api.Secret{Auth: &api.SecretAuth{ClientToken: "cherry", LeaseDuration: 3600}}},
+			},
+			expectToken: "cherry",
+			expectPath:  "/auth/dummy",
+		},
+		{
+			name:          "fails if login fails",
+			leaseDuration: time.Hour,
+			renewable:     false,
+			issued:        50 * time.Minute,
+			writeResults: []testResult{
+				{err: errors.New("login failed")},
+			},
+			expectPath:  "/auth/dummy",
+			expectError: errors.New("login request failed: login failed"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			readWriter := &mockReadWriter{
+				writeResults: test.writeResults,
+			}
+			tokenWriter := &testTokenWriter{}
+
+			handler := NewAuthHandler(AuthHandlerConfig{
+				Writer:      readWriter,
+				TokenWriter: tokenWriter,
+			})
+
+			handler.method = &testAuthHandler{path: "/auth/dummy"}
+			handler.leaseDuration = test.leaseDuration
+			handler.renewable = test.renewable
+			handler.issued = time.Now().Add(-test.issued)
+
+			err := handler.Renew(context.TODO())
+			if test.expectError == nil {
+				g.Expect(err).NotTo(HaveOccurred(), "renew error occurd but should not")
+			} else {
+				g.Expect(err.Error()).To(Equal(test.expectError.Error()))
+			}
+
+			g.Expect(tokenWriter.token).To(Equal(test.expectToken))
+			g.Expect(readWriter.writtenPath).To(Equal(test.expectPath))
+		})
+	}
+}
+
+func TestClose(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name         string
+		method       AuthMethod
+		expectRevoke bool
+	}{
+		{
+			name:         "revoke token issued by login",
+			method:       &testAuthHandler{path: "/auth/dummy"},
+			expectRevoke: true,
+		},
+		{
+			name:         "do not revoke static token",
+			method:       &testTokenMethod{token: "banana"},
+			expectRevoke: false,
+		},
+		{
+			name:         "do not revoke if not authenticated",
+			method:       nil,
+			expectRevoke: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			readWriter := &mockReadWriter{}
+			handler := NewAuthHandler(AuthHandlerConfig{
+				Writer:      readWriter,
+				TokenWriter: &testTokenWriter{},
+			})
+
+			handler.method = test.method
+			g.Expect(handler.Close()).To(Succeed())
+
+			if test.expectRevoke {
+				g.Expect(readWriter.writtenPath).To(Equal("auth/token/revoke-self"))
+			} else {
+				g.Expect(readWriter.writtenPath).To(BeEmpty())
+			}
+
+			g.Expect(handler.method).To(BeNil())
+		})
+	}
+}
+
+func TestWatcherLoginIfRenewalFails(t *testing.T) {
+	g := NewWithT(t)
+
+	var mu sync.Mutex
+	var logins int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/v1/auth/dummy/login":
+			logins++
+			// The second token is not renewable anymore which stops the renewal
+			fmt.Fprintf(w, `{"auth":{"client_token":"token-%d","lease_duration":3600,"renewable":%t}}`, logins, logins == 1)
+		case "/v1/auth/token/renew-self":
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"errors":["renewal failed"]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := api.DefaultConfig()
+	cfg.Address = server.URL
+	cfg.MaxRetries = 0
+	client, err := api.NewClient(cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	handler := NewAuthHandler(AuthHandlerConfig{
+		Reader:      client.Logical(),
+		Writer:      client.Logical(),
+		TokenWriter: client,
+		Watcher:     client,
+	})
+
+	g.Expect(handler.Authenticate(context.TODO(), &testAuthHandler{path: "auth/dummy/login"})).To(Succeed())
+	g.Eventually(client.Token, 5*time.Second).Should(Equal("token-2"))
+
+	mu.Lock()
+	g.Expect(logins).To(Equal(2))
+	mu.Unlock()
+}
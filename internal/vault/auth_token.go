@@ -0,0 +1,72 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+func init() {
+	registry.MustRegister("token", authToken)
+}
+
+type tokenMethod struct {
+	// path is a file which holds the token
+	path string
+
+	// secret is used to read the token from a kubernetes secret if no path is set
+	secret *secretKeyReader
+}
+
+// Static vault token read from either a kubernetes secret or a file
+func authToken(config *v1beta1.VaultAuthSpec, opts HandlerOptions) (AuthMethod, error) {
+	if config.Token == nil {
+		return nil, errors.New("token authentication requires either a secret reference or a path")
+	}
+
+	switch {
+	case config.Token.SecretRef != nil:
+		return &tokenMethod{
+			secret: &secretKeyReader{
+				client:    opts.Client,
+				namespace: opts.Namespace,
+				name:      config.Token.SecretRef.Name,
+				key:       config.Token.SecretRef.Key,
+			},
+		}, nil
+	case config.Token.Path != "":
+		return &tokenMethod{
+			path: config.Token.Path,
+		}, nil
+	default:
+		return nil, errors.New("token authentication requires either a secret reference or a path")
+	}
+}
+
+// Authenticate is not supported, a static token does not require a login
+func (t *tokenMethod) Authenticate(ctx context.Context) (string, http.Header, map[string]interface{}, error) {
+	return "", nil, nil, errors.New("token authentication does not support login")
+}
+
+func (t *tokenMethod) Token(ctx context.Context) (string, error) {
+	if t.secret != nil {
+		token, err := t.secret.read(ctx)
+		if err != nil {
+			return "", fmt.Errorf("error reading token: %w", err)
+		}
+
+		return strings.TrimSpace(token), nil
+	}
+
+	token, err := os.ReadFile(t.path)
+	if err != nil {
+		return "", fmt.Errorf("error reading token: %w", err)
+	}
+
+	return strings.TrimSpace(string(token)), nil
+}
@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+func TestAuthToken(t *testing.T) {
+	g := NewWithT(t)
+
+	file, err := os.CreateTemp(os.TempDir(), "token")
+	g.Expect(err).NotTo(HaveOccurred(), "failed creating test token file")
+	defer os.Remove(file.Name())
+	_, _ = file.Write([]byte("strawberry\n"))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "token",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"token": []byte("blueberry"),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		config      *v1beta1.VaultAuthSpec
+		expectToken string
+		expectError error
+	}{
+		{
+			name:        "fails if token is not configured",
+			config:      &v1beta1.VaultAuthSpec{},
+			expectError: errors.New("token authentication requires either a secret reference or a path"),
+		},
+		{
+			name: "fails if neither secret nor path is set",
+			config: &v1beta1.VaultAuthSpec{
+				Token: &v1beta1.VaultTokenSpec{},
+			},
+			expectError: errors.New("token authentication requires either a secret reference or a path"),
+		},
+		{
+			name: "fails if token file does not exist",
+			config: &v1beta1.VaultAuthSpec{
+				Token: &v1beta1.VaultTokenSpec{
+					Path: "/does-not-exist",
+				},
+			},
+			expectError: errors.New("error reading token: open /does-not-exist: no such file or directory"),
+		},
+		{
+			name: "fails if secret key does not exist",
+			config: &v1beta1.VaultAuthSpec{
+				Token: &v1beta1.VaultTokenSpec{
+					SecretRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "token"},
+						Key:                  "does-not-exist",
+					},
+				},
+			},
+			expectError: errors.New("error reading token: secret default/token has no key does-not-exist"),
+		},
+		{
+			name: "read token from file",
+			config: &v1beta1.VaultAuthSpec{
+				Token: &v1beta1.VaultTokenSpec{
+					Path: file.Name(),
+				},
+			},
+			expectToken: "strawberry",
+		},
+		{
+			name: "read token from secret",
+			config: &v1beta1.VaultAuthSpec{
+				Token: &v1beta1.VaultTokenSpec{
+					SecretRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "token"},
+						Key:                  "token",
+					},
+				},
+			},
+			expectToken: "blueberry",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method, err := authToken(test.config, HandlerOptions{
+				Client:    fake.NewClientBuilder().WithObjects(secret).Build(),
+				Namespace: "default",
+			})
+
+			var token string
+			if err == nil {
+				token, err = method.(TokenMethod).Token(context.TODO())
+			}
+
+			if test.expectError == nil {
+				g.Expect(err).NotTo(HaveOccurred(), "error occurd during token auth but should not")
+			} else {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(Equal(test.expectError.Error()))
+			}
+
+			g.Expect(token).To(Equal(test.expectToken))
+		})
+	}
+}
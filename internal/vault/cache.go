@@ -0,0 +1,319 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+// clientIdleTimeout is the time after which a cached client which is not used anymore gets closed
+const clientIdleTimeout = 15 * time.Minute
+
+// ClientCache holds authenticated vault clients which get reused across reconciles.
+// Clients are keyed by the address, TLS and auth settings of a vault spec.
+// A client is closed once it was replaced or not used for a while and all handlers using it are closed.
+type ClientCache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+
+	// users counts the open handlers of a client, retired clients are closed once they have no users left
+	users   map[*authenticatedClient]int
+	retired map[*authenticatedClient]bool
+
+	idleTimeout time.Duration
+	now         func() time.Time
+	newClient   func(context.Context, *v1beta1.VaultSpec, *tlsMaterial, HandlerOptions, logr.Logger) (*authenticatedClient, error)
+}
+
+// cacheEntry holds the client of a cache key
+type cacheEntry struct {
+	// mu serializes the login and token renewal of the key
+	mu     sync.Mutex
+	client *authenticatedClient
+
+	// waiters are the callers waiting for mu, the entry is not evicted while there are any
+	waiters  int
+	lastUsed time.Time
+}
+
+// NewClientCache creates an empty vault client cache
+func NewClientCache() *ClientCache {
+	return &ClientCache{
+		entries:     make(map[string]*cacheEntry),
+		users:       make(map[*authenticatedClient]int),
+		retired:     make(map[*authenticatedClient]bool),
+		idleTimeout: clientIdleTimeout,
+		now:         time.Now,
+		newClient:   newAuthenticatedClient,
+	}
+}
+
+// get returns a cached client for the vault spec and renews its token if required.
+// A new client is created and authenticated if there is none or the renewal failed.
+// Concurrent calls for the same key wait for each other so there is only one login.
+// The returned release func must be called once the client is not used anymore.
+func (c *ClientCache) get(ctx context.Context, config *v1beta1.VaultSpec, material *tlsMaterial, opts HandlerOptions, logger logr.Logger) (*authenticatedClient, func(), error) {
+	key, err := cacheKey(config, material, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	idle := c.evictIdle()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{}
+		c.entries[key] = entry
+	}
+
+	entry.waiters++
+	entry.lastUsed = c.now()
+	c.mu.Unlock()
+
+	c.closeClients(idle, logger)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.client != nil {
+		err := entry.client.auth.Renew(ctx)
+		if err == nil {
+			return entry.client, c.acquire(entry, entry.client, logger), nil
+		}
+
+		logger.Info("failed to renew cached vault client token, setup new client", "vault", entry.client.cfg.Address, "error", err.Error())
+		c.retire(entry.client, logger)
+		entry.client = nil
+	}
+
+	client, err := c.newClient(ctx, config, material, opts, logger)
+	if err != nil {
+		c.mu.Lock()
+		entry.waiters--
+		c.mu.Unlock()
+		return nil, nil, err
+	}
+
+	entry.client = client
+	return client, c.acquire(entry, client, logger), nil
+}
+
+// acquire registers a user of the client and returns the func releasing it
+func (c *ClientCache) acquire(entry *cacheEntry, client *authenticatedClient, logger logr.Logger) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.waiters--
+	c.users[client]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			c.users[client]--
+			entry.lastUsed = c.now()
+			closeClient := c.users[client] <= 0 && c.retired[client]
+			if c.users[client] <= 0 {
+				delete(c.users, client)
+				delete(c.retired, client)
+			}
+			c.mu.Unlock()
+
+			if closeClient {
+				c.closeClients([]*authenticatedClient{client}, logger)
+			}
+		})
+	}
+}
+
+// retire closes a replaced client immediately if it is not used, otherwise once its last user releases it
+func (c *ClientCache) retire(client *authenticatedClient, logger logr.Logger) {
+	c.mu.Lock()
+	inUse := c.users[client] > 0
+	if inUse {
+		c.retired[client] = true
+	}
+	c.mu.Unlock()
+
+	if !inUse {
+		c.closeClients([]*authenticatedClient{client}, logger)
+	}
+}
+
+// evictIdle removes the entries which were not used within the idle timeout and returns their clients.
+// The caller must hold the lock.
+func (c *ClientCache) evictIdle() []*authenticatedClient {
+	var idle []*authenticatedClient
+	for key, entry := range c.entries {
+		if entry.waiters > 0 || c.now().Sub(entry.lastUsed) < c.idleTimeout {
+			continue
+		}
+
+		if entry.client != nil && c.users[entry.client] > 0 {
+			continue
+		}
+
+		if entry.client != nil {
+			idle = append(idle, entry.client)
+		}
+
+		delete(c.entries, key)
+	}
+
+	return idle
+}
+
+// closeClients stops the token renewal of the clients and revokes their tokens
+func (c *ClientCache) closeClients(clients []*authenticatedClient, logger logr.Logger) {
+	for _, client := range clients {
+		if err := client.auth.Close(); err != nil {
+			logger.Error(err, "failed to close vault client", "vault", client.cfg.Address)
+		}
+	}
+}
+
+// Close stops the token renewal of all cached clients and revokes their tokens
+func (c *ClientCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	closeClient := func(client *authenticatedClient) {
+		if err := client.auth.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", client.cfg.Address, err))
+		}
+	}
+
+	for key, entry := range c.entries {
+		if entry.client != nil {
+			closeClient(entry.client)
+		}
+
+		delete(c.entries, key)
+	}
+
+	for client := range c.retired {
+		closeClient(client)
+		delete(c.retired, client)
+	}
+
+	return errors.Join(errs...)
+}
+
+// cacheKey builds the cache key from the connection, TLS material and auth settings.
+// The namespace is part of the key if the auth settings reference namespaced resources.
+func cacheKey(config *v1beta1.VaultSpec, material *tlsMaterial, opts HandlerOptions) (string, error) {
+	key := struct {
+		Address        string                `json:"address"`
+		VaultNamespace string                `json:"vaultNamespace,omitempty"`
+		TLSConfig      v1beta1.VaultTLSSpec  `json:"tlsConfig"`
+		TLSMaterial    string                `json:"tlsMaterial,omitempty"`
+		Auth           v1beta1.VaultAuthSpec `json:"auth"`
+		Namespace      string                `json:"namespace,omitempty"`
+	}{
+		Address:        config.Address,
+		VaultNamespace: config.Namespace,
+		TLSConfig:      config.TLSConfig,
+		TLSMaterial:    material.hash(),
+		Auth:           config.Auth,
+	}
+
+	if referencesNamespace(&config.Auth) {
+		key.Namespace = opts.Namespace
+	}
+
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+func referencesNamespace(auth *v1beta1.VaultAuthSpec) bool {
+	switch {
+	case auth.AppRole != nil:
+		return true
+	case auth.Token != nil && auth.Token.SecretRef != nil:
+		return true
+	case auth.JWT != nil && auth.JWT.ServiceAccountName != "":
+		return true
+	default:
+		return false
+	}
+}
+
+// authenticatedClient is a vault client which re-authenticates if a request
+// is denied because the token is not valid anymore
+type authenticatedClient struct {
+	ReadWriter
+	tokenReader Reader
+	cfg         *vaultapi.Config
+	auth        *AuthHandler
+	mounts      *kvMounts
+}
+
+func (c *authenticatedClient) ReadWithContext(ctx context.Context, path string) (*vaultapi.Secret, error) {
+	s, err := c.ReadWriter.ReadWithContext(ctx, path)
+	if c.reauthenticate(ctx, err) {
+		return c.ReadWriter.ReadWithContext(ctx, path)
+	}
+
+	return s, err
+}
+
+func (c *authenticatedClient) WriteWithContext(ctx context.Context, path string, data map[string]interface{}) (*vaultapi.Secret, error) {
+	s, err := c.ReadWriter.WriteWithContext(ctx, path, data)
+	if c.reauthenticate(ctx, err) {
+		return c.ReadWriter.WriteWithContext(ctx, path, data)
+	}
+
+	return s, err
+}
+
+func (c *authenticatedClient) DeleteWithContext(ctx context.Context, path string) (*vaultapi.Secret, error) {
+	s, err := c.ReadWriter.DeleteWithContext(ctx, path)
+	if c.reauthenticate(ctx, err) {
+		return c.ReadWriter.DeleteWithContext(ctx, path)
+	}
+
+	return s, err
+}
+
+func (c *authenticatedClient) ListWithContext(ctx context.Context, path string) (*vaultapi.Secret, error) {
+	s, err := c.ReadWriter.ListWithContext(ctx, path)
+	if c.reauthenticate(ctx, err) {
+		return c.ReadWriter.ListWithContext(ctx, path)
+	}
+
+	return s, err
+}
+
+// reauthenticate logs in again if the request was denied and the token is not valid anymore.
+// A denied request with a valid token (missing policy) does not trigger a new login.
+func (c *authenticatedClient) reauthenticate(ctx context.Context, err error) bool {
+	if !isPermissionDenied(err) {
+		return false
+	}
+
+	if _, err := c.tokenReader.ReadWithContext(ctx, "auth/token/lookup-self"); !isPermissionDenied(err) {
+		return false
+	}
+
+	return c.auth.Reauthenticate(ctx) == nil
+}
+
+func isPermissionDenied(err error) bool {
+	var respErr *vaultapi.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusForbidden
+}
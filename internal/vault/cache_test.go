@@ -0,0 +1,311 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
+	. "github.com/onsi/gomega"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+func TestCacheKey(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		a           *v1beta1.VaultSpec
+		aNamespace  string
+		b           *v1beta1.VaultSpec
+		bNamespace  string
+		expectEqual bool
+	}{
+		{
+			name:        "same key for same connection with different paths",
+			a:           &v1beta1.VaultSpec{Address: "https://vault", Path: "/secret/a"},
+			b:           &v1beta1.VaultSpec{Address: "https://vault", Path: "/secret/b", KVVersion: KVVersion2},
+			expectEqual: true,
+		},
+		{
+			name:        "same key for kubernetes auth in different namespaces",
+			a:           &v1beta1.VaultSpec{Address: "https://vault", Auth: v1beta1.VaultAuthSpec{Role: "fruit"}},
+			aNamespace:  "a",
+			b:           &v1beta1.VaultSpec{Address: "https://vault", Auth: v1beta1.VaultAuthSpec{Role: "fruit"}},
+			bNamespace:  "b",
+			expectEqual: true,
+		},
+		{
+			name:        "different key for different vault namespace",
+			a:           &v1beta1.VaultSpec{Address: "https://vault", Namespace: "team-a"},
+			b:           &v1beta1.VaultSpec{Address: "https://vault", Namespace: "team-b"},
+			expectEqual: false,
+		},
+		{
+			name:        "different key for different address",
+			a:           &v1beta1.VaultSpec{Address: "https://vault-a"},
+			b:           &v1beta1.VaultSpec{Address: "https://vault-b"},
+			expectEqual: false,
+		},
+		{
+			name:        "different key for different tls settings",
+			a:           &v1beta1.VaultSpec{Address: "https://vault"},
+			b:           &v1beta1.VaultSpec{Address: "https://vault", TLSConfig: v1beta1.VaultTLSSpec{Insecure: true}},
+			expectEqual: false,
+		},
+		{
+			name:        "different key for different auth role",
+			a:           &v1beta1.VaultSpec{Address: "https://vault", Auth: v1beta1.VaultAuthSpec{Role: "fruit"}},
+			b:           &v1beta1.VaultSpec{Address: "https://vault", Auth: v1beta1.VaultAuthSpec{Role: "vegetable"}},
+			expectEqual: false,
+		},
+		{
+			name: "different key for approle auth in different namespaces",
+			a: &v1beta1.VaultSpec{Address: "https://vault", Auth: v1beta1.VaultAuthSpec{
+				Type:    "approle",
+				AppRole: &v1beta1.VaultAppRoleSpec{},
+			}},
+			aNamespace: "a",
+			b: &v1beta1.VaultSpec{Address: "https://vault", Auth: v1beta1.VaultAuthSpec{
+				Type:    "approle",
+				AppRole: &v1beta1.VaultAppRoleSpec{},
+			}},
+			bNamespace:  "b",
+			expectEqual: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, err := cacheKey(test.a, nil, HandlerOptions{Namespace: test.aNamespace})
+			g.Expect(err).NotTo(HaveOccurred())
+			b, err := cacheKey(test.b, nil, HandlerOptions{Namespace: test.bNamespace})
+			g.Expect(err).NotTo(HaveOccurred())
+
+			if test.expectEqual {
+				g.Expect(a).To(Equal(b))
+			} else {
+				g.Expect(a).NotTo(Equal(b))
+			}
+		})
+	}
+}
+
+type deniedReadWriter struct {
+	mockReadWriter
+	tokenValid bool
+	reads      int
+}
+
+func (rw *deniedReadWriter) ReadWithContext(ctx context.Context, path string) (*api.Secret, error) {
+	if path == "auth/token/lookup-self" && rw.tokenValid {
+		return &api.Secret{}, nil
+	}
+
+	rw.reads++
+	if rw.reads == 1 || path == "auth/token/lookup-self" {
+		return nil, &api.ResponseError{StatusCode: http.StatusForbidden}
+	}
+
+	return &api.Secret{Data: map[string]interface{}{"fruit": "banana"}}, nil
+}
+
+func TestAuthenticatedClientReauthenticate(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name         string
+		tokenValid   bool
+		expectLogins int
+		expectError  bool
+	}{
+		{
+			name:         "login again and retry if token is not valid anymore",
+			tokenValid:   false,
+			expectLogins: 2,
+			expectError:  false,
+		},
+		{
+			name:         "do not login again if token is valid but permission is denied",
+			tokenValid:   true,
+			expectLogins: 1,
+			expectError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			login := &mockReadWriter{
+				writeResult: testResult{
+					secret: &api.Secret{
+						Auth: &api.SecretAuth{
+							ClientToken: "banana",
+						},
+					},
+				},
+			}
+
+			auth := NewAuthHandler(AuthHandlerConfig{
+				Writer:      login,
+				TokenWriter: &testTokenWriter{},
+			})
+
+			g.Expect(auth.Authenticate(context.TODO(), &testAuthHandler{path: "/auth/dummy"})).To(Succeed())
+
+			readWriter := &deniedReadWriter{tokenValid: test.tokenValid}
+			c := &authenticatedClient{
+				ReadWriter:  readWriter,
+				tokenReader: readWriter,
+				auth:        auth,
+			}
+
+			_, err := c.ReadWithContext(context.TODO(), "/secret/food")
+			if test.expectError {
+				var respErr *api.ResponseError
+				g.Expect(errors.As(err, &respErr)).To(BeTrue())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			g.Expect(login.writeCalls).To(Equal(test.expectLogins))
+		})
+	}
+}
+
+// testCache returns a cache which creates clients authenticated against the given read writer
+func testCache(rw *mockReadWriter, logins *int32) *ClientCache {
+	c := NewClientCache()
+	c.newClient = func(ctx context.Context, config *v1beta1.VaultSpec, material *tlsMaterial, opts HandlerOptions, logger logr.Logger) (*authenticatedClient, error) {
+		atomic.AddInt32(logins, 1)
+		time.Sleep(10 * time.Millisecond)
+
+		auth := NewAuthHandler(AuthHandlerConfig{
+			Writer:      rw,
+			TokenWriter: &testTokenWriter{},
+		})
+
+		if err := auth.Authenticate(ctx, &testAuthHandler{path: "/auth/dummy"}); err != nil {
+			return nil, err
+		}
+
+		return &authenticatedClient{
+			ReadWriter:  rw,
+			tokenReader: rw,
+			cfg:         &api.Config{Address: config.Address},
+			auth:        auth,
+		}, nil
+	}
+
+	return c
+}
+
+func loginResult() testResult {
+	return testResult{
+		secret: &api.Secret{
+			Auth: &api.SecretAuth{
+				ClientToken: "banana",
+			},
+		},
+	}
+}
+
+func isClosed(c *authenticatedClient) bool {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+	return c.auth.method == nil
+}
+
+func TestClientCacheConcurrentMisses(t *testing.T) {
+	g := NewWithT(t)
+
+	var logins int32
+	cache := testCache(&mockReadWriter{writeResult: loginResult()}, &logins)
+	config := &v1beta1.VaultSpec{Address: "https://vault"}
+
+	var wg sync.WaitGroup
+	clients := make([]*authenticatedClient, 10)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, release, err := cache.get(context.TODO(), config, nil, HandlerOptions{}, logr.Discard())
+			g.Expect(err).NotTo(HaveOccurred())
+			defer release()
+			clients[i] = client
+		}(i)
+	}
+
+	wg.Wait()
+	g.Expect(logins).To(Equal(int32(1)))
+	for _, client := range clients {
+		g.Expect(client).To(BeIdenticalTo(clients[0]))
+	}
+}
+
+func TestClientCacheReplacedClientInUse(t *testing.T) {
+	g := NewWithT(t)
+
+	var logins int32
+	rw := &mockReadWriter{writeResult: loginResult()}
+	cache := testCache(rw, &logins)
+	config := &v1beta1.VaultSpec{Address: "https://vault"}
+
+	first, releaseFirst, err := cache.get(context.TODO(), config, nil, HandlerOptions{}, logr.Discard())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Expire the token of the first client and fail its renewal
+	first.auth.mu.Lock()
+	first.auth.leaseDuration = time.Minute
+	first.auth.issued = time.Now().Add(-time.Hour)
+	first.auth.mu.Unlock()
+	rw.writeResults = []testResult{{err: errors.New("login failed")}}
+
+	second, releaseSecond, err := cache.get(context.TODO(), config, nil, HandlerOptions{}, logr.Discard())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(second).NotTo(BeIdenticalTo(first))
+	g.Expect(logins).To(Equal(int32(2)))
+	g.Expect(isClosed(first)).To(BeFalse(), "replaced client must not be closed while in use")
+
+	releaseFirst()
+	g.Expect(isClosed(first)).To(BeTrue(), "replaced client must be closed once released")
+
+	releaseSecond()
+	g.Expect(isClosed(second)).To(BeFalse(), "current client must stay open after release")
+}
+
+func TestClientCacheEvictIdle(t *testing.T) {
+	g := NewWithT(t)
+
+	var logins int32
+	cache := testCache(&mockReadWriter{writeResult: loginResult()}, &logins)
+	now := time.Now()
+	cache.now = func() time.Time {
+		return now
+	}
+
+	idle, release, err := cache.get(context.TODO(), &v1beta1.VaultSpec{Address: "https://vault-a"}, nil, HandlerOptions{}, logr.Discard())
+	g.Expect(err).NotTo(HaveOccurred())
+	release()
+
+	inUse, releaseInUse, err := cache.get(context.TODO(), &v1beta1.VaultSpec{Address: "https://vault-b"}, nil, HandlerOptions{}, logr.Discard())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	now = now.Add(clientIdleTimeout + time.Second)
+	_, releaseOther, err := cache.get(context.TODO(), &v1beta1.VaultSpec{Address: "https://vault-c"}, nil, HandlerOptions{}, logr.Discard())
+	g.Expect(err).NotTo(HaveOccurred())
+	defer releaseOther()
+
+	g.Expect(isClosed(idle)).To(BeTrue(), "idle client must be closed")
+	g.Expect(isClosed(inUse)).To(BeFalse(), "client in use must not be evicted")
+	g.Expect(cache.entries).To(HaveLen(2))
+
+	releaseInUse()
+	g.Expect(cache.Close()).To(Succeed())
+	g.Expect(isClosed(inUse)).To(BeTrue())
+}
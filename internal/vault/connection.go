@@ -0,0 +1,108 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+// resolveConnection returns the vault spec with the settings of the referenced connection.
+// A VaultConnection is looked up in the namespace of the resource.
+func resolveConnection(ctx context.Context, config *v1beta1.VaultSpec, opts HandlerOptions) (*v1beta1.VaultSpec, error) {
+	if config.ConnectionRef == nil {
+		return config, nil
+	}
+
+	if opts.Client == nil {
+		return nil, ErrConnectionNotFound
+	}
+
+	var conn v1beta1.VaultConnectionSpec
+	ref := config.ConnectionRef
+
+	switch ref.Kind {
+	case "", v1beta1.VaultConnectionKind:
+		var c v1beta1.VaultConnection
+		if err := opts.Client.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: ref.Name}, &c); err != nil {
+			return nil, connectionError(ref, err)
+		}
+
+		conn = c.Spec
+	case v1beta1.ClusterVaultConnectionKind:
+		var c v1beta1.ClusterVaultConnection
+		if err := opts.Client.Get(ctx, client.ObjectKey{Name: ref.Name}, &c); err != nil {
+			return nil, connectionError(ref, err)
+		}
+
+		conn = c.Spec
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %s", ErrConnectionNotFound, ref.Kind)
+	}
+
+	resolved := config.DeepCopy()
+	resolved.ConnectionRef = nil
+	resolved.Address = conn.Address
+	resolved.Namespace = conn.Namespace
+	resolved.TLSConfig = conn.TLSConfig
+	resolved.Auth = conn.Auth
+
+	return resolved, nil
+}
+
+// connectionOptions returns the options used to resolve the kubernetes resources referenced by the vault spec.
+// The TLS material and auth references of a ClusterVaultConnection are resolved in the controller namespace
+// while the ones of a VaultConnection or the spec itself are resolved in the namespace of the resource.
+func connectionOptions(config *v1beta1.VaultSpec, opts HandlerOptions) HandlerOptions {
+	if config.ConnectionRef != nil && config.ConnectionRef.Kind == v1beta1.ClusterVaultConnectionKind {
+		opts.Namespace = opts.ControllerNamespace
+	}
+
+	return opts
+}
+
+func connectionError(ref *v1beta1.VaultConnectionReference, err error) error {
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%w: %s", ErrConnectionNotFound, ref.Name)
+	}
+
+	return err
+}
+
+// CheckHealth checks if the vault server of a connection is reachable, initialized and unsealed
+// TLS material referenced from secrets and config maps is resolved in the namespace of the options,
+// for a ClusterVaultConnection this is the controller namespace. References can not be resolved without a namespace.
+func CheckHealth(ctx context.Context, conn *v1beta1.VaultConnectionSpec, opts HandlerOptions) (*vaultapi.HealthResponse, error) {
+	material, err := loadTLSMaterial(ctx, conn.TLSConfig, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := validateTLS(conn.TLSConfig, material); err != nil {
+		return nil, err
+	}
+
+	c, _, err := newClient(&v1beta1.VaultSpec{
+		Address:   conn.Address,
+		Namespace: conn.Namespace,
+		TLSConfig: conn.TLSConfig,
+	}, material)
+	if err != nil {
+		return nil, err
+	}
+
+	health, err := c.Sys().HealthWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !health.Initialized || health.Sealed {
+		return health, ErrVaultSealed
+	}
+
+	return health, nil
+}
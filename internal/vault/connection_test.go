@@ -0,0 +1,253 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+func TestResolveConnection(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(v1beta1.AddToScheme(scheme)).To(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1beta1.VaultConnection{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "vault",
+				Namespace: "default",
+			},
+			Spec: v1beta1.VaultConnectionSpec{
+				Address: "https://namespaced-vault:8200",
+				Auth: v1beta1.VaultAuthSpec{
+					Type: "approle",
+				},
+			},
+		},
+		&v1beta1.ClusterVaultConnection{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "vault",
+			},
+			Spec: v1beta1.VaultConnectionSpec{
+				Address:   "https://cluster-vault:8200",
+				Namespace: "team-a",
+			},
+		},
+	).Build()
+
+	tests := []struct {
+		name        string
+		config      *v1beta1.VaultSpec
+		namespace   string
+		expect      *v1beta1.VaultSpec
+		expectError error
+	}{
+		{
+			name: "keep spec without connection reference",
+			config: &v1beta1.VaultSpec{
+				Address: "https://vault:8200",
+				Path:    "/secret/food",
+			},
+			namespace: "default",
+			expect: &v1beta1.VaultSpec{
+				Address: "https://vault:8200",
+				Path:    "/secret/food",
+			},
+		},
+		{
+			name: "use settings of namespaced connection",
+			config: &v1beta1.VaultSpec{
+				ConnectionRef: &v1beta1.VaultConnectionReference{Name: "vault"},
+				Address:       "https://ignored:8200",
+				Path:          "/secret/food",
+				KVVersion:     KVVersion2,
+			},
+			namespace: "default",
+			expect: &v1beta1.VaultSpec{
+				Address: "https://namespaced-vault:8200",
+				Auth: v1beta1.VaultAuthSpec{
+					Type: "approle",
+				},
+				Path:      "/secret/food",
+				KVVersion: KVVersion2,
+			},
+		},
+		{
+			name: "use settings of cluster connection",
+			config: &v1beta1.VaultSpec{
+				ConnectionRef: &v1beta1.VaultConnectionReference{
+					Kind: v1beta1.ClusterVaultConnectionKind,
+					Name: "vault",
+				},
+				Path: "/secret/food",
+			},
+			namespace: "other",
+			expect: &v1beta1.VaultSpec{
+				Address:   "https://cluster-vault:8200",
+				Namespace: "team-a",
+				Path:      "/secret/food",
+			},
+		},
+		{
+			name: "fails if namespaced connection is in another namespace",
+			config: &v1beta1.VaultSpec{
+				ConnectionRef: &v1beta1.VaultConnectionReference{Name: "vault"},
+			},
+			namespace:   "other",
+			expectError: errors.New("Referenced vault connection not found: vault"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolved, err := resolveConnection(context.TODO(), test.config, HandlerOptions{
+				Client:    c,
+				Namespace: test.namespace,
+			})
+
+			if test.expectError != nil {
+				g.Expect(err).To(MatchError(test.expectError.Error()))
+				g.Expect(errors.Is(err, ErrConnectionNotFound)).To(BeTrue())
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(resolved).To(Equal(test.expect))
+		})
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		response    string
+		expectError error
+	}{
+		{
+			name:     "healthy vault",
+			response: `{"initialized":true,"sealed":false,"version":"1.13.0"}`,
+		},
+		{
+			name:        "sealed vault",
+			response:    `{"initialized":true,"sealed":true,"version":"1.13.0"}`,
+			expectError: ErrVaultSealed,
+		},
+		{
+			name:        "uninitialized vault",
+			response:    `{"initialized":false,"sealed":true,"version":"1.13.0"}`,
+			expectError: ErrVaultSealed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(test.response))
+			}))
+			defer server.Close()
+
+			health, err := CheckHealth(context.TODO(), &v1beta1.VaultConnectionSpec{
+				Address: server.URL,
+			}, HandlerOptions{})
+
+			if test.expectError != nil {
+				g.Expect(err).To(Equal(test.expectError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			g.Expect(health.Version).To(Equal("1.13.0"))
+		})
+	}
+}
+
+func TestConnectionOptions(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name      string
+		ref       *v1beta1.VaultConnectionReference
+		namespace string
+	}{
+		{
+			name:      "resolve in resource namespace without connection reference",
+			namespace: "default",
+		},
+		{
+			name:      "resolve in resource namespace for namespaced connection",
+			ref:       &v1beta1.VaultConnectionReference{Name: "vault"},
+			namespace: "default",
+		},
+		{
+			name: "resolve in controller namespace for cluster connection",
+			ref: &v1beta1.VaultConnectionReference{
+				Kind: v1beta1.ClusterVaultConnectionKind,
+				Name: "vault",
+			},
+			namespace: "k8svault-system",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := connectionOptions(&v1beta1.VaultSpec{ConnectionRef: test.ref}, HandlerOptions{
+				Namespace:           "default",
+				ControllerNamespace: "k8svault-system",
+			})
+
+			g.Expect(opts.Namespace).To(Equal(test.namespace))
+		})
+	}
+}
+
+func TestNewHandlerClusterConnection(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(v1beta1.AddToScheme(scheme)).To(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1beta1.ClusterVaultConnection{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "vault",
+			},
+			Spec: v1beta1.VaultConnectionSpec{
+				Address: "https://cluster-vault:8200",
+				Auth: v1beta1.VaultAuthSpec{
+					Type: "approle",
+					AppRole: &v1beta1.VaultAppRoleSpec{
+						SecretRef: corev1.LocalObjectReference{Name: "approle"},
+					},
+				},
+			},
+		},
+	).Build()
+
+	_, err := NewHandler(context.TODO(), &v1beta1.VaultSpec{
+		ConnectionRef: &v1beta1.VaultConnectionReference{
+			Kind: v1beta1.ClusterVaultConnectionKind,
+			Name: "vault",
+		},
+		Path: "/secret/food",
+	}, HandlerOptions{
+		Client:    c,
+		Namespace: "default",
+	}, logr.Discard())
+
+	g.Expect(err).To(MatchError("auth settings reference kubernetes resources but the controller namespace is unknown, the controller must be started with --controller-namespace"))
+}
@@ -0,0 +1,51 @@
+package vault
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// hashKey keys the data hashes so they can't be used to guess secret values.
+// It is generated on startup unless configured with SetHashKey. A random key does not survive a restart,
+// the data hashes stored in the status and the audit log hashes from before can't be compared anymore.
+var hashKey = randomHashKey()
+
+func randomHashKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("failed to generate hash key: %s", err))
+	}
+
+	return key
+}
+
+// SetHashKey sets the key of the data hashes, it must be called before any hash gets computed.
+// Hashes computed with a different key, for example before a restart, do not match anymore.
+func SetHashKey(key []byte) {
+	hashKey = key
+}
+
+// DataHash returns the HMAC-SHA256 of the given fields and their values keyed with the controller hash key
+func DataHash(data map[string]interface{}, fields []string) (string, error) {
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	values := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		values[field] = data[field]
+	}
+
+	// Map keys are sorted by the encoder
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, hashKey)
+	_, _ = mac.Write(b)
+	return fmt.Sprintf("%x", mac.Sum(nil)), nil
+}
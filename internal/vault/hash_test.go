@@ -0,0 +1,39 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDataHash(t *testing.T) {
+	g := NewWithT(t)
+
+	data := map[string]interface{}{
+		"fruit":     "banana",
+		"vegetable": "carrot",
+	}
+
+	hash, err := DataHash(data, []string{"fruit"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(hash).To(HaveLen(64))
+	g.Expect(hash).NotTo(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte(`{"fruit":"banana"}`)))))
+
+	// Fields which are not listed are not hashed
+	same, err := DataHash(map[string]interface{}{"fruit": "banana"}, []string{"fruit"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(same).To(Equal(hash))
+
+	empty, err := DataHash(data, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(empty).To(BeEmpty())
+
+	defer SetHashKey(hashKey)
+	SetHashKey([]byte("other"))
+
+	rekeyed, err := DataHash(data, []string{"fruit"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rekeyed).NotTo(Equal(hash))
+}
@@ -0,0 +1,152 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// KV secrets engine versions
+const (
+	KVVersion1 = 1
+	KVVersion2 = 2
+)
+
+// kvMounts caches the detected kv mounts of a vault client by their mount path
+type kvMounts struct {
+	mu       sync.RWMutex
+	versions map[string]int
+}
+
+func newKVMounts() *kvMounts {
+	return &kvMounts{
+		versions: make(map[string]int),
+	}
+}
+
+// lookup returns the longest cached mount the path belongs to
+func (m *kvMounts) lookup(p string) (string, int, bool) {
+	if m == nil {
+		return "", 0, false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var mount string
+	var version int
+	for prefix, v := range m.versions {
+		if strings.HasPrefix(p, prefix) && len(prefix) > len(mount) {
+			mount, version = prefix, v
+		}
+	}
+
+	return mount, version, mount != ""
+}
+
+func (m *kvMounts) store(mount string, version int) {
+	if m == nil || mount == "" {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.versions[mount] = version
+}
+
+// kvMount looks up the mount path and the kv version of the secrets engine the given path belongs to.
+// If the version is explicitly set no lookup is made, for kv version 2 the mount is the first path element.
+// Detected mounts are cached per client, if the version can not be detected kv version 1 is assumed.
+func (h *VaultHandler) kvMount(ctx context.Context, p string) (string, int) {
+	p = strings.TrimPrefix(p, "/")
+	switch h.kvVersion {
+	case KVVersion1:
+		return "", KVVersion1
+	case KVVersion2:
+		return strings.SplitN(p, "/", 2)[0] + "/", KVVersion2
+	}
+
+	if mount, version, ok := h.mounts.lookup(p); ok {
+		return mount, version
+	}
+
+	mount, version := "", KVVersion1
+	s, err := h.c.ReadWithContext(ctx, path.Join("sys/internal/ui/mounts", p))
+
+	switch {
+	case err != nil:
+		h.logger.Info("failed to detect kv version, fallback to kv version 1", "path", p, "error", err.Error())
+	case s != nil && s.Data != nil:
+		mount, _ = s.Data["path"].(string)
+		if options, ok := s.Data["options"].(map[string]interface{}); ok && options["version"] == "2" {
+			version = KVVersion2
+		}
+
+		h.mounts.store(mount, version)
+	}
+
+	return mount, version
+}
+
+// kvDataPath returns the kv version 2 data path for a secret.
+// Paths which already contain the data prefix are returned as they are.
+func kvDataPath(mount, p string) string {
+	p = strings.TrimPrefix(p, "/")
+	rel := strings.TrimPrefix(p, mount)
+	if strings.HasPrefix(rel, "data/") {
+		return p
+	}
+
+	return path.Join(mount, "data", rel)
+}
+
+// kvMetadataPath returns the kv version 2 metadata path for a secret which is used to list keys
+func kvMetadataPath(mount, p string) string {
+	p = strings.TrimPrefix(p, "/")
+	return path.Join(mount, "metadata", strings.TrimPrefix(p, mount))
+}
+
+// kvSecretVersion returns the secret version from the kv version 2 metadata
+func kvSecretVersion(metadata interface{}) int {
+	m, ok := metadata.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+
+	var version int64
+	switch v := m["version"].(type) {
+	case json.Number:
+		version, _ = v.Int64()
+	case string:
+		version, _ = strconv.ParseInt(v, 10, 64)
+	case float64:
+		version = int64(v)
+	case int:
+		version = int64(v)
+	}
+
+	return int(version)
+}
+
+// isCASMismatch returns true if vault rejected a write because the check-and-set version did not match
+func isCASMismatch(err error) bool {
+	var respErr *vaultapi.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+
+	for _, e := range respErr.Errors {
+		if strings.Contains(e, "check-and-set") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,116 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+)
+
+func TestKVDataPath(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name   string
+		mount  string
+		path   string
+		expect string
+	}{
+		{
+			name:   "adds data prefix after the mount",
+			mount:  "secret/",
+			path:   "/secret/env/app",
+			expect: "secret/data/env/app",
+		},
+		{
+			name:   "adds data prefix after nested mount",
+			mount:  "team/kv/",
+			path:   "team/kv/app",
+			expect: "team/kv/data/app",
+		},
+		{
+			name:   "keeps path which already has the data prefix",
+			mount:  "secret/",
+			path:   "/secret/data/env/app",
+			expect: "secret/data/env/app",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g.Expect(kvDataPath(test.mount, test.path)).To(Equal(test.expect))
+		})
+	}
+}
+
+func TestKVMount(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name             string
+		kvVersion        int
+		paths            []string
+		readWriter       *mockReadWriter
+		expectMount      string
+		expectVersion    int
+		expectMountCalls int
+	}{
+		{
+			name:             "detects the mount once per client",
+			paths:            []string{"/secret/food", "secret/env/app", "/secret/food"},
+			readWriter:       &mockReadWriter{mountResult: kv2MountResult()},
+			expectMount:      "secret/",
+			expectVersion:    KVVersion2,
+			expectMountCalls: 1,
+		},
+		{
+			name:  "does not cache failed detections",
+			paths: []string{"/secret/food", "/secret/food"},
+			readWriter: &mockReadWriter{
+				mountResult: testResult{
+					err: errors.New("permission denied"),
+				},
+			},
+			expectVersion:    KVVersion1,
+			expectMountCalls: 2,
+		},
+		{
+			name:             "skips detection if kv version 2 is set",
+			kvVersion:        KVVersion2,
+			paths:            []string{"/kv/food", "/kv/food"},
+			readWriter:       &mockReadWriter{mountResult: kv2MountResult()},
+			expectMount:      "kv/",
+			expectVersion:    KVVersion2,
+			expectMountCalls: 0,
+		},
+		{
+			name:             "skips detection if kv version 1 is set",
+			kvVersion:        KVVersion1,
+			paths:            []string{"/secret/food"},
+			readWriter:       &mockReadWriter{mountResult: kv2MountResult()},
+			expectVersion:    KVVersion1,
+			expectMountCalls: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := &VaultHandler{
+				logger:    logr.Discard(),
+				c:         test.readWriter,
+				kvVersion: test.kvVersion,
+				mounts:    newKVMounts(),
+			}
+
+			for _, p := range test.paths {
+				mount, version := handler.kvMount(context.TODO(), p)
+				g.Expect(mount).To(Equal(test.expectMount))
+				g.Expect(version).To(Equal(test.expectVersion))
+			}
+
+			g.Expect(test.readWriter.mountCalls).To(Equal(test.expectMountCalls))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package vault
+
+import (
+	"context"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/DoodleScheduling/k8svault-controller/internal/metrics"
+)
+
+// instrumentedReadWriter records metrics for all requests sent to a vault server
+type instrumentedReadWriter struct {
+	ReadWriter
+	address string
+}
+
+func (rw *instrumentedReadWriter) ReadWithContext(ctx context.Context, path string) (*vaultapi.Secret, error) {
+	start := time.Now()
+	s, err := rw.ReadWriter.ReadWithContext(ctx, path)
+	metrics.ObserveVaultRequest(rw.address, metrics.OperationRead, time.Since(start), err)
+	return s, err
+}
+
+func (rw *instrumentedReadWriter) WriteWithContext(ctx context.Context, path string, data map[string]interface{}) (*vaultapi.Secret, error) {
+	start := time.Now()
+	s, err := rw.ReadWriter.WriteWithContext(ctx, path, data)
+	metrics.ObserveVaultRequest(rw.address, metrics.OperationWrite, time.Since(start), err)
+	return s, err
+}
+
+func (rw *instrumentedReadWriter) DeleteWithContext(ctx context.Context, path string) (*vaultapi.Secret, error) {
+	start := time.Now()
+	s, err := rw.ReadWriter.DeleteWithContext(ctx, path)
+	metrics.ObserveVaultRequest(rw.address, metrics.OperationDelete, time.Since(start), err)
+	return s, err
+}
+
+func (rw *instrumentedReadWriter) ListWithContext(ctx context.Context, path string) (*vaultapi.Secret, error) {
+	start := time.Now()
+	s, err := rw.ReadWriter.ListWithContext(ctx, path)
+	metrics.ObserveVaultRequest(rw.address, metrics.OperationList, time.Since(start), err)
+	return s, err
+}
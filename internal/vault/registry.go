@@ -2,6 +2,7 @@ package vault
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
@@ -11,7 +12,7 @@ var registry *AuthMethodRegistry = &AuthMethodRegistry{
 	methods: make(map[string]NewAuthMethod),
 }
 
-type NewAuthMethod func(conf *v1beta1.VaultAuthSpec) (AuthMethod, error)
+type NewAuthMethod func(conf *v1beta1.VaultAuthSpec, opts HandlerOptions) (AuthMethod, error)
 
 type AuthMethodRegistry struct {
 	methods map[string]NewAuthMethod
@@ -39,12 +40,23 @@ func (r *AuthMethodRegistry) MustRegister(name string, init NewAuthMethod) {
 	}
 }
 
-func (r *AuthMethodRegistry) Invoke(name string, conf *v1beta1.VaultAuthSpec) (AuthMethod, error) {
+func (r *AuthMethodRegistry) Invoke(name string, conf *v1beta1.VaultAuthSpec, opts HandlerOptions) (AuthMethod, error) {
 	for k, v := range r.methods {
 		if k == name {
-			return v(conf)
+			return v(conf, opts)
 		}
 	}
 
 	return nil, fmt.Errorf("auth method %s is unknown", name)
 }
+
+// authMountPath returns the configured auth mount path or the default mount path of an auth method.
+// The mount path is normalized to a leading and no trailing slash, auth/kubernetes/ becomes /auth/kubernetes.
+func authMountPath(mountPath, defaultMountPath string) string {
+	mountPath = strings.Trim(mountPath, "/")
+	if mountPath == "" {
+		return defaultMountPath
+	}
+
+	return "/" + mountPath
+}
@@ -0,0 +1,44 @@
+package vault
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestAuthMountPath(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name      string
+		mountPath string
+		expect    string
+	}{
+		{
+			name:      "default mount path if not set",
+			mountPath: "",
+			expect:    "/auth/kubernetes",
+		},
+		{
+			name:      "configured mount path",
+			mountPath: "/auth/k8s-prod",
+			expect:    "/auth/k8s-prod",
+		},
+		{
+			name:      "configured mount path without leading slash",
+			mountPath: "auth/k8s-prod",
+			expect:    "/auth/k8s-prod",
+		},
+		{
+			name:      "configured mount path with trailing slash",
+			mountPath: "/auth/k8s-prod/",
+			expect:    "/auth/k8s-prod",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g.Expect(authMountPath(test.mountPath, "/auth/kubernetes")).To(Equal(test.expect))
+		})
+	}
+}
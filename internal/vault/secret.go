@@ -0,0 +1,62 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretKeyReader reads a single key from a kubernetes secret
+type secretKeyReader struct {
+	client    client.Reader
+	namespace string
+	name      string
+	key       string
+}
+
+func (s *secretKeyReader) read(ctx context.Context) (string, error) {
+	if s.client == nil {
+		return "", errors.New("no kubernetes client available to read secret")
+	}
+
+	secret := &corev1.Secret{}
+	if err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: s.name}, secret); err != nil {
+		return "", fmt.Errorf("failed to get secret %s/%s: %w", s.namespace, s.name, err)
+	}
+
+	value, ok := secret.Data[s.key]
+	if !ok || len(value) == 0 {
+		return "", fmt.Errorf("secret %s/%s has no key %s", s.namespace, s.name, s.key)
+	}
+
+	return string(value), nil
+}
+
+// configMapKeyReader reads a single key from a kubernetes config map
+type configMapKeyReader struct {
+	client    client.Reader
+	namespace string
+	name      string
+	key       string
+}
+
+func (c *configMapKeyReader) read(ctx context.Context) (string, error) {
+	if c.client == nil {
+		return "", errors.New("no kubernetes client available to read config map")
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: c.namespace, Name: c.name}, cm); err != nil {
+		return "", fmt.Errorf("failed to get config map %s/%s: %w", c.namespace, c.name, err)
+	}
+
+	value, ok := cm.Data[c.key]
+	if !ok || len(value) == 0 {
+		return "", fmt.Errorf("config map %s/%s has no key %s", c.namespace, c.name, c.key)
+	}
+
+	return value, nil
+}
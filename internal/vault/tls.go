@@ -0,0 +1,350 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+// tlsMaterial holds PEM-encoded TLS material which is not read from files on the controller pod
+type tlsMaterial struct {
+	caCert     []byte
+	clientCert []byte
+	clientKey  []byte
+
+	// clientCertSource and clientKeySource describe where the client certificate and key were read from
+	clientCertSource string
+	clientKeySource  string
+}
+
+// hash identifies the TLS material, it is empty if there is none
+func (t *tlsMaterial) hash() string {
+	if t == nil || (len(t.caCert) == 0 && len(t.clientCert) == 0 && len(t.clientKey) == 0) {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, b := range [][]byte{t.caCert, t.clientCert, t.clientKey} {
+		_, _ = h.Write(b)
+		_, _ = h.Write([]byte{0})
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// loadTLSMaterial reads the inline TLS material and the one referenced from secrets and config maps.
+// References are resolved in the namespace of the options. They can not be resolved if no namespace is given
+// as it is the case for cluster connections if the controller namespace is unknown.
+// Each CA certificate source is validated, errors name the source at fault.
+func loadTLSMaterial(ctx context.Context, spec v1beta1.VaultTLSSpec, opts HandlerOptions) (*tlsMaterial, error) {
+	t := &tlsMaterial{}
+	var caCerts []tlsSource
+
+	if spec.CACertPEM != "" {
+		caCerts = append(caCerts, tlsSource{name: "inline caCertPEM", value: spec.CACertPEM})
+	}
+
+	if spec.ClientCertPEM != "" {
+		t.clientCert = []byte(spec.ClientCertPEM)
+		t.clientCertSource = "inline clientCertPEM"
+	}
+
+	if ref := spec.CACertSecretRef; ref != nil {
+		src, err := readSecretSource(ctx, opts, ref)
+		if err != nil {
+			return nil, tlsConfigError("ca certificate", src.name, err)
+		}
+
+		caCerts = append(caCerts, src)
+	}
+
+	if ref := spec.CACertConfigMapRef; ref != nil {
+		src := tlsSource{name: fmt.Sprintf("config map %s/%s key %s", opts.Namespace, ref.Name, ref.Key)}
+		if opts.Namespace == "" {
+			return nil, tlsConfigError("ca certificate", fmt.Sprintf("config map %s key %s", ref.Name, ref.Key), errNamespaceUnknown)
+		}
+
+		v, err := (&configMapKeyReader{
+			client:    opts.Client,
+			namespace: opts.Namespace,
+			name:      ref.Name,
+			key:       ref.Key,
+		}).read(ctx)
+		if err != nil {
+			return nil, tlsConfigError("ca certificate", src.name, err)
+		}
+
+		src.value = v
+		caCerts = append(caCerts, src)
+	}
+
+	if ref := spec.ClientCertSecretRef; ref != nil {
+		src, err := readSecretSource(ctx, opts, ref)
+		if err != nil {
+			return nil, tlsConfigError("client certificate", src.name, err)
+		}
+
+		t.clientCert = []byte(src.value)
+		t.clientCertSource = src.name
+	}
+
+	if ref := spec.ClientKeySecretRef; ref != nil {
+		src, err := readSecretSource(ctx, opts, ref)
+		if err != nil {
+			return nil, tlsConfigError("client key", src.name, err)
+		}
+
+		t.clientKey = []byte(src.value)
+		t.clientKeySource = src.name
+	}
+
+	// Multiple CA sources are merged into a single bundle
+	for _, src := range caCerts {
+		if _, err := parseCertificates([]byte(src.value)); err != nil {
+			return nil, tlsConfigError("ca certificate", src.name, err)
+		}
+
+		t.caCert = append(t.caCert, []byte(src.value)...)
+		t.caCert = append(t.caCert, '\n')
+	}
+
+	return t, nil
+}
+
+// errNamespaceUnknown is returned for references which can not be resolved as the namespace is unknown
+var errNamespaceUnknown = errors.New("namespace is unknown, the controller must be started with --controller-namespace")
+
+// tlsSource is PEM-encoded TLS material and a description of where it was read from
+type tlsSource struct {
+	name  string
+	value string
+}
+
+func readSecretSource(ctx context.Context, opts HandlerOptions, ref *corev1.SecretKeySelector) (tlsSource, error) {
+	if opts.Namespace == "" {
+		return tlsSource{name: fmt.Sprintf("secret %s key %s", ref.Name, ref.Key)}, errNamespaceUnknown
+	}
+
+	src := tlsSource{name: fmt.Sprintf("secret %s/%s key %s", opts.Namespace, ref.Name, ref.Key)}
+	v, err := (&secretKeyReader{
+		client:    opts.Client,
+		namespace: opts.Namespace,
+		name:      ref.Name,
+		key:       ref.Key,
+	}).read(ctx)
+
+	src.value = v
+	return src, err
+}
+
+func tlsConfigError(kind, source string, err error) error {
+	return fmt.Errorf("%w: %s from %s: %s", ErrTLSConfigInvalid, kind, source, err.Error())
+}
+
+// validateTLS validates the certificates of the TLS spec and the loaded TLS material
+// and returns their expiry. The CA certificate file takes precedence over the loaded CA material
+// which takes precedence over the CA path, the same as the vault client does.
+func validateTLS(spec v1beta1.VaultTLSSpec, material *tlsMaterial) (*v1beta1.VaultTLSStatus, error) {
+	var status v1beta1.VaultTLSStatus
+
+	switch {
+	case spec.CACert != "":
+		certs, err := readCertificateFile(spec.CACert)
+		if err != nil {
+			return nil, tlsConfigError("ca certificate", "file "+spec.CACert, err)
+		}
+
+		status.CACertExpiry = earliestExpiry(certs)
+	case material != nil && len(material.caCert) > 0:
+		certs, err := parseCertificates(material.caCert)
+		if err != nil {
+			return nil, tlsConfigError("ca certificate", "loaded material", err)
+		}
+
+		status.CACertExpiry = earliestExpiry(certs)
+	case spec.CAPath != "":
+		certs, err := readCertificateDir(spec.CAPath)
+		if err != nil {
+			return nil, tlsConfigError("ca certificates", "path "+spec.CAPath, err)
+		}
+
+		status.CACertExpiry = earliestExpiry(certs)
+	}
+
+	var cert tls.Certificate
+	var source string
+	var err error
+	switch {
+	case material != nil && (len(material.clientCert) > 0 || len(material.clientKey) > 0):
+		if spec.ClientCert != "" || spec.ClientKey != "" {
+			return nil, fmt.Errorf("%w: client certificate and key must either both be files or be loaded from secrets or inline", ErrTLSConfigInvalid)
+		}
+
+		if len(material.clientCert) == 0 || len(material.clientKey) == 0 {
+			return nil, fmt.Errorf("%w: both client certificate and key must be provided", ErrTLSConfigInvalid)
+		}
+
+		source = fmt.Sprintf("%s and key from %s", material.clientCertSource, material.clientKeySource)
+		cert, err = tls.X509KeyPair(material.clientCert, material.clientKey)
+	case spec.ClientCert != "" && spec.ClientKey != "":
+		source = fmt.Sprintf("file %s and key from file %s", spec.ClientCert, spec.ClientKey)
+		cert, err = tls.LoadX509KeyPair(spec.ClientCert, spec.ClientKey)
+	case spec.ClientCert != "" || spec.ClientKey != "":
+		return nil, fmt.Errorf("%w: both client certificate and key must be provided", ErrTLSConfigInvalid)
+	}
+
+	if err != nil {
+		return nil, tlsConfigError("client certificate", source, err)
+	}
+
+	if len(cert.Certificate) > 0 {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, tlsConfigError("client certificate", source, err)
+		}
+
+		status.ClientCertExpiry = earliestExpiry([]*x509.Certificate{leaf})
+	}
+
+	if status.CACertExpiry == nil && status.ClientCertExpiry == nil {
+		return nil, nil
+	}
+
+	return &status, nil
+}
+
+// parseCertificates parses all PEM-encoded certificates, it fails if there are none
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM-encoded certificate found")
+	}
+
+	return certs, nil
+}
+
+func readCertificateFile(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCertificates(data)
+}
+
+// readCertificateDir reads the certificates of all files in a directory
+func readCertificateDir(dir string) ([]*x509.Certificate, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		c, err := readCertificateFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		certs = append(certs, c...)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM-encoded certificate found")
+	}
+
+	return certs, nil
+}
+
+func earliestExpiry(certs []*x509.Certificate) *metav1.Time {
+	var expiry *metav1.Time
+	for _, cert := range certs {
+		if expiry == nil || cert.NotAfter.Before(expiry.Time) {
+			t := metav1.NewTime(cert.NotAfter)
+			expiry = &t
+		}
+	}
+
+	return expiry
+}
+
+// configureTLS applies the TLS spec and the loaded TLS material to the vault client config.
+// The TLS settings are expected to be validated using validateTLS.
+func configureTLS(cfg *vaultapi.Config, spec v1beta1.VaultTLSSpec, material *tlsMaterial) error {
+	tlsConfig := convertTLSSpec(spec)
+	if material != nil {
+		tlsConfig.CACertBytes = material.caCert
+	}
+
+	if err := cfg.ConfigureTLS(tlsConfig); err != nil {
+		return fmt.Errorf("%w: %s", ErrTLSConfigInvalid, err.Error())
+	}
+
+	if material == nil || (len(material.clientCert) == 0 && len(material.clientKey) == 0) {
+		return nil
+	}
+
+	cert, err := tls.X509KeyPair(material.clientCert, material.clientKey)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrTLSConfigInvalid, err.Error())
+	}
+
+	transport, ok := cfg.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		return errors.New("unexpected vault client transport")
+	}
+
+	// Always present the client certificate, same as the vault client does for certificate files
+	transport.TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &cert, nil
+	}
+
+	return nil
+}
+
+func convertTLSSpec(spec v1beta1.VaultTLSSpec) *vaultapi.TLSConfig {
+	return &vaultapi.TLSConfig{
+		CACert:        spec.CACert,
+		CAPath:        spec.CAPath,
+		ClientCert:    spec.ClientCert,
+		ClientKey:     spec.ClientKey,
+		TLSServerName: spec.ServerName,
+		Insecure:      spec.Insecure,
+	}
+}
@@ -0,0 +1,414 @@
+package vault
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+)
+
+// testCertificate returns a PEM-encoded self signed certificate and its key
+func testCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "vault"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestLoadTLSMaterial(t *testing.T) {
+	g := NewWithT(t)
+	cert, key := testCertificate(t)
+	ca := string(cert)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "tls",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"ca.crt":  cert,
+				"tls.crt": cert,
+				"tls.key": key,
+				"invalid": []byte("invalid"),
+			},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ca",
+				Namespace: "default",
+			},
+			Data: map[string]string{
+				"ca.crt": ca,
+			},
+		},
+	).Build()
+
+	secretRef := func(key string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "tls"},
+			Key:                  key,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		spec        v1beta1.VaultTLSSpec
+		namespace   string
+		expect      *tlsMaterial
+		expectError error
+	}{
+		{
+			name:   "no material for file based settings",
+			spec:   v1beta1.VaultTLSSpec{CACert: "/etc/vault/ca.crt"},
+			expect: &tlsMaterial{},
+		},
+		{
+			name: "inline material",
+			spec: v1beta1.VaultTLSSpec{
+				CACertPEM:     ca,
+				ClientCertPEM: "inline-cert",
+			},
+			expect: &tlsMaterial{
+				caCert:           []byte(ca + "\n"),
+				clientCert:       []byte("inline-cert"),
+				clientCertSource: "inline clientCertPEM",
+			},
+		},
+		{
+			name: "merge ca certificates and read client certificate from secret",
+			spec: v1beta1.VaultTLSSpec{
+				CACertPEM:       ca,
+				CACertSecretRef: secretRef("ca.crt"),
+				CACertConfigMapRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ca"},
+					Key:                  "ca.crt",
+				},
+				ClientCertSecretRef: secretRef("tls.crt"),
+				ClientKeySecretRef:  secretRef("tls.key"),
+			},
+			namespace: "default",
+			expect: &tlsMaterial{
+				caCert:           []byte(ca + "\n" + ca + "\n" + ca + "\n"),
+				clientCert:       cert,
+				clientKey:        key,
+				clientCertSource: "secret default/tls key tls.crt",
+				clientKeySource:  "secret default/tls key tls.key",
+			},
+		},
+		{
+			name: "fails if secret reference can not be resolved without namespace",
+			spec: v1beta1.VaultTLSSpec{
+				CACertPEM:       ca,
+				CACertSecretRef: secretRef("ca.crt"),
+			},
+			expectError: errors.New("Invalid vault TLS configuration: ca certificate from secret tls key ca.crt: namespace is unknown, the controller must be started with --controller-namespace"),
+		},
+		{
+			name: "fails if config map reference can not be resolved without namespace",
+			spec: v1beta1.VaultTLSSpec{
+				CACertConfigMapRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ca"},
+					Key:                  "ca.crt",
+				},
+			},
+			expectError: errors.New("Invalid vault TLS configuration: ca certificate from config map ca key ca.crt: namespace is unknown, the controller must be started with --controller-namespace"),
+		},
+		{
+			name: "fails if secret key does not exist",
+			spec: v1beta1.VaultTLSSpec{
+				ClientKeySecretRef: secretRef("does-not-exist"),
+			},
+			namespace:   "default",
+			expectError: errors.New("Invalid vault TLS configuration: client key from secret default/tls key does-not-exist: secret default/tls has no key does-not-exist"),
+		},
+		{
+			name: "fails if ca certificate is not PEM-encoded",
+			spec: v1beta1.VaultTLSSpec{
+				CACertSecretRef: secretRef("invalid"),
+			},
+			namespace:   "default",
+			expectError: errors.New("Invalid vault TLS configuration: ca certificate from secret default/tls key invalid: no PEM-encoded certificate found"),
+		},
+		{
+			name: "fails if config map does not exist",
+			spec: v1beta1.VaultTLSSpec{
+				CACertConfigMapRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ca"},
+					Key:                  "ca.crt",
+				},
+			},
+			namespace:   "other",
+			expectError: errors.New(`Invalid vault TLS configuration: ca certificate from config map other/ca key ca.crt: failed to get config map other/ca: configmaps "ca" not found`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			material, err := loadTLSMaterial(context.TODO(), test.spec, HandlerOptions{
+				Client:    c,
+				Namespace: test.namespace,
+			})
+
+			if test.expectError != nil {
+				g.Expect(err).To(MatchError(test.expectError.Error()))
+				g.Expect(errors.Is(err, ErrTLSConfigInvalid)).To(BeTrue())
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(material).To(Equal(test.expect))
+		})
+	}
+}
+
+func TestConfigureTLS(t *testing.T) {
+	g := NewWithT(t)
+	cert, key := testCertificate(t)
+
+	tests := []struct {
+		name              string
+		spec              v1beta1.VaultTLSSpec
+		material          *tlsMaterial
+		expectClientCert  bool
+		expectCertificate bool
+		expectError       bool
+	}{
+		{
+			name:              "use ca certificate material",
+			material:          &tlsMaterial{caCert: cert},
+			expectCertificate: true,
+		},
+		{
+			name:             "use client certificate material",
+			material:         &tlsMaterial{clientCert: cert, clientKey: key},
+			expectClientCert: true,
+		},
+		{
+			name:        "fails if ca certificate material is invalid",
+			material:    &tlsMaterial{caCert: []byte("invalid")},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := vaultapi.DefaultConfig()
+			err := configureTLS(cfg, test.spec, test.material)
+
+			if test.expectError {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+
+			tlsConfig := cfg.HttpClient.Transport.(*http.Transport).TLSClientConfig
+			g.Expect(tlsConfig.RootCAs != nil).To(Equal(test.expectCertificate))
+
+			if test.expectClientCert {
+				g.Expect(tlsConfig.GetClientCertificate).NotTo(BeNil())
+				c, err := tlsConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(c.Certificate).To(HaveLen(1))
+			}
+		})
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	g := NewWithT(t)
+	cert, key := testCertificate(t)
+
+	dir := t.TempDir()
+	writeFile := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		return p
+	}
+
+	caFile := writeFile("ca.crt", cert)
+	certFile := writeFile("tls.crt", cert)
+	keyFile := writeFile("tls.key", key)
+	invalidFile := writeFile("invalid.crt", []byte("invalid"))
+
+	caPath := filepath.Join(dir, "ca")
+	if err := os.Mkdir(caPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(caPath, "ca.crt"), cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(cert)
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	g.Expect(err).NotTo(HaveOccurred())
+	expiry := metav1.NewTime(parsed.NotAfter)
+
+	tests := []struct {
+		name        string
+		spec        v1beta1.VaultTLSSpec
+		material    *tlsMaterial
+		expect      *v1beta1.VaultTLSStatus
+		expectError string
+	}{
+		{
+			name: "no status without certificates",
+			spec: v1beta1.VaultTLSSpec{Insecure: true},
+		},
+		{
+			name: "expiry of ca certificate and client certificate files",
+			spec: v1beta1.VaultTLSSpec{
+				CACert:     caFile,
+				ClientCert: certFile,
+				ClientKey:  keyFile,
+			},
+			expect: &v1beta1.VaultTLSStatus{
+				CACertExpiry:     &expiry,
+				ClientCertExpiry: &expiry,
+			},
+		},
+		{
+			name: "expiry of ca path",
+			spec: v1beta1.VaultTLSSpec{CAPath: caPath},
+			expect: &v1beta1.VaultTLSStatus{
+				CACertExpiry: &expiry,
+			},
+		},
+		{
+			name:     "expiry of loaded material",
+			material: &tlsMaterial{caCert: cert, clientCert: cert, clientKey: key},
+			expect: &v1beta1.VaultTLSStatus{
+				CACertExpiry:     &expiry,
+				ClientCertExpiry: &expiry,
+			},
+		},
+		{
+			name:        "fails if ca certificate file does not exist",
+			spec:        v1beta1.VaultTLSSpec{CACert: "/does-not-exist/ca.crt"},
+			expectError: "Invalid vault TLS configuration: ca certificate from file /does-not-exist/ca.crt: open /does-not-exist/ca.crt: no such file or directory",
+		},
+		{
+			name:        "fails if ca certificate file is invalid",
+			spec:        v1beta1.VaultTLSSpec{CACert: invalidFile},
+			expectError: "Invalid vault TLS configuration: ca certificate from file " + invalidFile + ": no PEM-encoded certificate found",
+		},
+		{
+			name:        "fails if ca path contains an invalid file",
+			spec:        v1beta1.VaultTLSSpec{CAPath: dir},
+			expectError: "Invalid vault TLS configuration: ca certificates from path " + dir + ": invalid.crt: no PEM-encoded certificate found",
+		},
+		{
+			name: "fails if client key file does not match",
+			spec: v1beta1.VaultTLSSpec{
+				ClientCert: certFile,
+				ClientKey:  invalidFile,
+			},
+			expectError: "Invalid vault TLS configuration: client certificate from file " + certFile + " and key from file " + invalidFile + ": tls: failed to find any PEM data in key input",
+		},
+		{
+			name:        "fails if client key file is missing",
+			spec:        v1beta1.VaultTLSSpec{ClientCert: certFile},
+			expectError: "Invalid vault TLS configuration: both client certificate and key must be provided",
+		},
+		{
+			name:        "fails if client key material is missing",
+			material:    &tlsMaterial{clientCert: cert, clientCertSource: "inline clientCertPEM"},
+			expectError: "Invalid vault TLS configuration: both client certificate and key must be provided",
+		},
+		{
+			name:        "fails if client certificate material is mixed with files",
+			spec:        v1beta1.VaultTLSSpec{ClientKey: keyFile},
+			material:    &tlsMaterial{clientCert: cert},
+			expectError: "Invalid vault TLS configuration: client certificate and key must either both be files or be loaded from secrets or inline",
+		},
+		{
+			name: "fails if client key material is invalid",
+			material: &tlsMaterial{
+				clientCert:       cert,
+				clientCertSource: "inline clientCertPEM",
+				clientKey:        []byte("invalid"),
+				clientKeySource:  "secret default/tls key tls.key",
+			},
+			expectError: "Invalid vault TLS configuration: client certificate from inline clientCertPEM and key from secret default/tls key tls.key: tls: failed to find any PEM data in key input",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, err := validateTLS(test.spec, test.material)
+
+			if test.expectError != "" {
+				g.Expect(err).To(MatchError(test.expectError))
+				g.Expect(errors.Is(err, ErrTLSConfigInvalid)).To(BeTrue())
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(status).To(Equal(test.expect))
+		})
+	}
+}
+
+func TestConvertTLSSpec(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(convertTLSSpec(v1beta1.VaultTLSSpec{
+		CACert:     "/etc/vault/ca.crt",
+		CAPath:     "/etc/vault/ca",
+		ServerName: "vault",
+	})).To(Equal(&vaultapi.TLSConfig{
+		CACert:        "/etc/vault/ca.crt",
+		CAPath:        "/etc/vault/ca",
+		TLSServerName: "vault",
+	}))
+}
@@ -3,12 +3,20 @@ package vault
 import (
 	"context"
 	"errors"
+	"path"
+	"sort"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/hashicorp/vault/api"
 	vaultapi "github.com/hashicorp/vault/api"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
+	"github.com/DoodleScheduling/k8svault-controller/internal/metrics"
+	"github.com/DoodleScheduling/k8svault-controller/internal/tracing"
 )
 
 // Common errors
@@ -18,60 +26,193 @@ var (
 	ErrUnsupportedAuthType = errors.New("Unsupported vault authentication")
 	ErrVaultConfig         = errors.New("Failed to setup default vault configuration")
 	ErrPathNotFound        = errors.New("Vault path not found")
+	ErrCASMismatch         = errors.New("Vault path was concurrently modified, check-and-set retries exhausted")
+	ErrConnectionNotFound  = errors.New("Referenced vault connection not found")
+	ErrVaultSealed         = errors.New("Vault is not initialized or sealed")
+	ErrTLSConfigInvalid    = errors.New("Invalid vault TLS configuration")
 )
 
+// maxCASRetries is the number of times a kv version 2 write gets retried if the check-and-set version did not match
+const maxCASRetries = 3
+
+// HandlerOptions holds the dependencies required to resolve kubernetes resources referenced by a vault spec
+type HandlerOptions struct {
+	// Client is used to read kubernetes secrets referenced by the vault spec and to request service account tokens
+	Client client.Client
+
+	// Namespace is the namespace of the resource the vault spec belongs to
+	Namespace string
+
+	// ControllerNamespace is the namespace the controller runs in.
+	// TLS material referenced by a ClusterVaultConnection is resolved in it.
+	ControllerNamespace string
+
+	// TokenRequestAudiences are the audiences service account tokens may be requested for with jwt authentication.
+	// Requesting service account tokens is disabled if empty.
+	TokenRequestAudiences []string
+
+	// Cache holds authenticated vault clients which are reused if set
+	Cache *ClientCache
+}
+
 // NewHandler creates a vault client handler
 // If the config holds no vault address it will fallback to the env VAULT_ADDRESS
-func NewHandler(config *v1beta1.VaultSpec, logger logr.Logger) (*VaultHandler, error) {
-	cfg := vaultapi.DefaultConfig()
+func NewHandler(ctx context.Context, config *v1beta1.VaultSpec, opts HandlerOptions, logger logr.Logger) (*VaultHandler, error) {
+	ctx, span := tracing.Start(ctx, "vault.NewHandler", tracing.AttributeVaultPath.String(config.Path))
+	h, err := newHandler(ctx, config, opts, logger)
+	if err == nil {
+		span.SetAttributes(tracing.AttributeVaultAddress.String(h.Address()))
+	}
 
-	if cfg == nil {
-		return nil, ErrVaultConfig
+	tracing.End(span, err)
+	return h, err
+}
+
+func newHandler(ctx context.Context, config *v1beta1.VaultSpec, opts HandlerOptions, logger logr.Logger) (*VaultHandler, error) {
+	connOpts := connectionOptions(config, opts)
+	config, err := resolveConnection(ctx, config, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	if config.Address != "" {
-		cfg.Address = config.Address
+	opts = connOpts
+	if opts.Namespace == "" && referencesNamespace(&config.Auth) {
+		return nil, errors.New("auth settings reference kubernetes resources but the controller namespace is unknown, the controller must be started with --controller-namespace")
 	}
 
-	// Overwrite TLS setttings with individual settings
-	_ = cfg.ConfigureTLS(convertTLSSpec(config.TLSConfig))
+	// TLS material from secrets and config maps is loaded on every call to pick up rotated certificates
+	material, err := loadTLSMaterial(ctx, config.TLSConfig, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsStatus, err := validateTLS(config.TLSConfig, material)
+	if err != nil {
+		return nil, err
+	}
+
+	var c *authenticatedClient
+	var release func()
+	if opts.Cache != nil {
+		c, release, err = opts.Cache.get(ctx, config, material, opts, logger)
+	} else {
+		c, err = newAuthenticatedClient(ctx, config, material, opts, logger)
+	}
 
-	client, err := vaultapi.NewClient(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	h := &VaultHandler{
-		cfg:    cfg,
-		c:      client.Logical(),
-		logger: logger,
+	return &VaultHandler{
+		cfg:       c.cfg,
+		c:         c,
+		kvVersion: config.KVVersion,
+		mounts:    c.mounts,
+		tls:       tlsStatus,
+		logger:    logger,
+		release:   release,
+	}, nil
+}
+
+// newAuthenticatedClient creates a new vault client and authenticates it
+func newAuthenticatedClient(ctx context.Context, config *v1beta1.VaultSpec, material *tlsMaterial, opts HandlerOptions, logger logr.Logger) (*authenticatedClient, error) {
+	vaultClient, cfg, err := newClient(config, material)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("setup vault client", "vault", cfg.Address, "namespace", vaultClient.Namespace())
+
+	// The login may happen in a different namespace than the data requests,
+	// the issued token is passed to both clients.
+	authClient := vaultClient
+	var tokenWriter TokenWriter = vaultClient
+	if config.Auth.Namespace != "" {
+		authClient = vaultClient.WithNamespace(config.Auth.Namespace)
+		tokenWriter = tokenWriters{vaultClient, authClient}
 	}
 
-	logger.Info("setup vault client", "vault", cfg.Address)
+	authOpts := AuthHandlerConfig{
+		Reader:      authClient.Logical(),
+		Writer:      authClient.Logical(),
+		TokenWriter: tokenWriter,
+		Address:     cfg.Address,
+		Logger:      logger,
+	}
 
-	opts := AuthHandlerConfig{
-		Writer:      client.Logical(),
-		TokenWriter: client,
+	// Only cached clients live long enough to renew their tokens in the background
+	if opts.Cache != nil {
+		authOpts.Watcher = authClient
 	}
 
-	if err = setupAuth(h, opts, &config.Auth); err != nil {
+	auth, err := setupAuth(ctx, authOpts, &config.Auth, opts)
+	if err != nil {
 		return nil, err
 	}
 
-	return h, nil
+	return &authenticatedClient{
+		ReadWriter:  &instrumentedReadWriter{ReadWriter: vaultClient.Logical(), address: cfg.Address},
+		tokenReader: authClient.Logical(),
+		cfg:         cfg,
+		auth:        auth,
+		mounts:      newKVMounts(),
+	}, nil
+}
+
+// newClient creates an unauthenticated vault client
+func newClient(config *v1beta1.VaultSpec, material *tlsMaterial) (*vaultapi.Client, *vaultapi.Config, error) {
+	cfg := vaultapi.DefaultConfig()
+
+	if cfg == nil {
+		return nil, nil, ErrVaultConfig
+	}
+
+	if config.Address != "" {
+		cfg.Address = config.Address
+	}
+
+	// Overwrite TLS setttings with individual settings
+	if err := configureTLS(cfg, config.TLSConfig, material); err != nil {
+		return nil, nil, err
+	}
+
+	vaultClient, err := vaultapi.NewClient(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Requests are traced as part of the span of the calling operation.
+	// The transport is wrapped once the client is created as the vault client expects an *http.Transport while setting it up.
+	cfg.HttpClient.Transport = otelhttp.NewTransport(cfg.HttpClient.Transport)
+
+	if config.Namespace != "" {
+		vaultClient.SetNamespace(config.Namespace)
+	}
+
+	return vaultClient, cfg, nil
 }
 
 type Writer interface {
-	Write(path string, data map[string]interface{}) (*api.Secret, error)
+	WriteWithContext(ctx context.Context, path string, data map[string]interface{}) (*api.Secret, error)
 }
 
 type Reader interface {
-	Read(path string) (*api.Secret, error)
+	ReadWithContext(ctx context.Context, path string) (*api.Secret, error)
+}
+
+type Deleter interface {
+	DeleteWithContext(ctx context.Context, path string) (*api.Secret, error)
+}
+
+type Lister interface {
+	ListWithContext(ctx context.Context, path string) (*api.Secret, error)
 }
 
 type ReadWriter interface {
 	Reader
 	Writer
+	Deleter
+	Lister
 }
 
 // Mapper retrieves mapping configuration
@@ -81,21 +222,170 @@ type Mapper interface {
 	GetFieldMapping() []v1beta1.FieldMapping
 }
 
+// WriteResult describes the outcome of a write
+type WriteResult struct {
+	// Written is true if the path was updated
+	Written bool
+
+	// Fields are the destination fields which hold the source value after the write.
+	// Existing fields with a different value which were not overwritten are not included.
+	Fields []string
+
+	// Added are the destination fields which did not exist before the write
+	Added []string
+
+	// Updated are the existing destination fields whose value was overwritten
+	Updated []string
+
+	// Skipped are the existing destination fields with a different value which were not overwritten
+	Skipped []string
+
+	// Path is the vault path the request was sent to, for kv version 2 mounts the data path
+	Path string
+
+	// KVVersion is the kv version of the mount
+	KVVersion int
+
+	// Version is the kv version 2 secret version after the write
+	Version int
+
+	// DataHash is the keyed hash of the fields and their values
+	DataHash string
+
+	// PreviousHash is the keyed hash of the fields and their values before the write
+	PreviousHash string
+}
+
 // VaultHandler
 type VaultHandler struct {
-	c      ReadWriter
-	cfg    *vaultapi.Config
-	logger logr.Logger
+	c         ReadWriter
+	cfg       *vaultapi.Config
+	kvVersion int
+	mounts    *kvMounts
+	tls       *v1beta1.VaultTLSStatus
+	logger    logr.Logger
+	release   func()
+}
+
+// Close releases the vault client of the handler.
+// A cached client stays authenticated for other handlers until it gets evicted from the cache.
+func (h *VaultHandler) Close() {
+	if h.release != nil {
+		h.release()
+		h.release = nil
+	}
+}
+
+// Address returns the effective vault address, which is the env default if none was specified
+func (h *VaultHandler) Address() string {
+	if h.cfg == nil {
+		return ""
+	}
+
+	return h.cfg.Address
+}
+
+// TLSStatus returns the expiry of the certificates used to connect to vault
+// It is nil if no certificates are configured.
+func (h *VaultHandler) TLSStatus() *v1beta1.VaultTLSStatus {
+	return h.tls
 }
 
 // Write writes secrets to vault defined by the mapper
-func (h *VaultHandler) Write(writer Mapper, srcData map[string]interface{}) (bool, error) {
-	var writeBack bool
+// Writes to kv version 2 paths use check-and-set with the version observed during the read
+// and get retried if the path was modified in the meantime.
+func (h *VaultHandler) Write(ctx context.Context, writer Mapper, srcData map[string]interface{}) (WriteResult, error) {
+	ctx, span := tracing.Start(ctx, "vault.Write", h.spanAttributes(writer.GetPath())...)
+
+	var result WriteResult
+	dstPath, version := h.resolvePath(ctx, writer.GetPath())
+	_, err := h.retryCAS(dstPath, func() (bool, error) {
+		var err error
+		result, err = h.write(ctx, dstPath, version, writer, srcData)
+		return result.Written, err
+	})
+
+	if err == nil {
+		written := len(result.Added) + len(result.Updated)
+		metrics.AddFields(h.Address(), metrics.OutcomeWritten, written)
+		metrics.AddFields(h.Address(), metrics.OutcomeUnchanged, len(result.Fields)-written)
+		metrics.AddFields(h.Address(), metrics.OutcomeSkipped, len(result.Skipped))
+		span.SetAttributes(
+			attribute.Int("vault.fields.written", written),
+			attribute.Int("vault.fields.skipped", len(result.Skipped)),
+		)
+	}
+
+	tracing.End(span, err)
+	return result, err
+}
+
+// Remove removes the given fields from a vault path and returns the fields which existed
+// The path gets deleted if no fields are left.
+func (h *VaultHandler) Remove(ctx context.Context, path string, fields []string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "vault.Remove", h.spanAttributes(path)...)
+
+	var removed []string
+	dstPath, version := h.resolvePath(ctx, path)
+	_, err := h.retryCAS(dstPath, func() (bool, error) {
+		var err error
+		removed, err = h.remove(ctx, dstPath, version, fields)
+		return len(removed) > 0, err
+	})
+
+	tracing.End(span, err)
+	return removed, err
+}
+
+// spanAttributes are the attributes of spans for requests to a vault path
+func (h *VaultHandler) spanAttributes(path string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		tracing.AttributeVaultAddress.String(h.Address()),
+		tracing.AttributeVaultPath.String(path),
+	}
+}
+
+// resolvePath returns the path used for requests and the kv version of the mount
+func (h *VaultHandler) resolvePath(ctx context.Context, path string) (string, int) {
+	mount, version := h.kvMount(ctx, path)
+	if version == KVVersion2 {
+		path = kvDataPath(mount, path)
+	}
+
+	return path, version
+}
+
+// retryCAS retries fn as long as it fails with a check-and-set mismatch
+func (h *VaultHandler) retryCAS(dstPath string, fn func() (bool, error)) (bool, error) {
+	for attempt := 0; ; attempt++ {
+		changed, err := fn()
+		if err == nil || !isCASMismatch(err) {
+			return changed, err
+		}
+
+		if attempt >= maxCASRetries {
+			return changed, ErrCASMismatch
+		}
+
+		h.logger.Info("path was modified concurrently, retry write", "dstPath", dstPath, "attempt", attempt+1)
+	}
+}
+
+func (h *VaultHandler) write(ctx context.Context, dstPath string, version int, writer Mapper, srcData map[string]interface{}) (WriteResult, error) {
+	result := WriteResult{
+		Path:      dstPath,
+		KVVersion: version,
+	}
 
 	// Ignore error if there is no path at the destination
-	data, err := h.Read(writer.GetPath())
+	data, casVersion, err := h.read(ctx, dstPath, version)
 	if err != nil && err != ErrPathNotFound {
-		return writeBack, err
+		return result, err
+	}
+
+	previous := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		previous[k] = v
 	}
 
 	// If no field mapping is configured all fields get mapped with their source field name
@@ -121,7 +411,7 @@ func (h *VaultHandler) Write(writer Mapper, srcData map[string]interface{}) (boo
 		// If k8s secret field does not exists return an error
 		srcValue, ok := srcData[srcField]
 		if !ok {
-			return writeBack, ErrFieldNotAvailable
+			return result, ErrFieldNotAvailable
 		}
 
 		_, existingField := data[dstField]
@@ -130,66 +420,231 @@ func (h *VaultHandler) Write(writer Mapper, srcData map[string]interface{}) (boo
 		case !existingField:
 			h.logger.Info("found new field to write", "dstField", dstField)
 			data[dstField] = srcValue
-			writeBack = true
+			result.Written = true
+			result.Added = append(result.Added, dstField)
 		case data[dstField] == srcValue:
 			h.logger.Info("skipping field, no update required", "dstField", dstField)
 		case writer.IsForceApply():
 			data[dstField] = srcValue
-			writeBack = true
+			result.Written = true
+			result.Updated = append(result.Updated, dstField)
 		default:
 			h.logger.Info("skipping field, it already exists in vault and force apply is not enabled", "dstField", dstField)
+			result.Skipped = append(result.Skipped, dstField)
+			continue
 		}
+
+		result.Fields = append(result.Fields, dstField)
 	}
 
-	if writeBack {
+	sort.Strings(result.Fields)
+	sort.Strings(result.Added)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Skipped)
+	result.Version = casVersion
+
+	if result.Written {
 		// Finally write the secret back
-		_, err = h.c.Write(writer.GetPath(), data)
+		s, err := h.c.WriteWithContext(ctx, dstPath, kvPayload(data, version, casVersion))
 		if err != nil {
-			return writeBack, err
+			return result, err
+		}
+
+		if version == KVVersion2 && s != nil {
+			result.Version = kvSecretVersion(s.Data)
+		}
+	}
+
+	result.PreviousHash, err = DataHash(previous, result.Fields)
+	if err != nil {
+		return result, err
+	}
+
+	result.DataHash, err = DataHash(data, result.Fields)
+	return result, err
+}
+
+func (h *VaultHandler) remove(ctx context.Context, dstPath string, version int, fields []string) ([]string, error) {
+	data, casVersion, err := h.read(ctx, dstPath, version)
+	if err == ErrPathNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, field := range fields {
+		if _, ok := data[field]; ok {
+			h.logger.Info("removing field from vault", "dstField", field, "dstPath", dstPath)
+			delete(data, field)
+			removed = append(removed, field)
 		}
 	}
 
-	return writeBack, nil
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if len(data) == 0 {
+		h.logger.Info("no fields left, deleting path", "dstPath", dstPath)
+		_, err = h.c.DeleteWithContext(ctx, dstPath)
+		return removed, err
+	}
+
+	_, err = h.c.WriteWithContext(ctx, dstPath, kvPayload(data, version, casVersion))
+	return removed, err
+}
+
+// kvPayload returns the data to be written, kv version 2 expects the fields wrapped in data
+// and the check-and-set version as option
+func kvPayload(data map[string]interface{}, version, casVersion int) map[string]interface{} {
+	if version != KVVersion2 {
+		return data
+	}
+
+	return map[string]interface{}{
+		"data": data,
+		"options": map[string]interface{}{
+			"cas": casVersion,
+		},
+	}
 }
 
 // Read vault path and return data map
 // Return empty map if no data exists
-func (h *VaultHandler) Read(path string) (map[string]interface{}, error) {
-	s, err := h.c.Read(path)
+func (h *VaultHandler) Read(ctx context.Context, path string) (map[string]interface{}, error) {
+	ctx, span := tracing.Start(ctx, "vault.Read", h.spanAttributes(path)...)
+
+	path, version := h.resolvePath(ctx, path)
+	data, _, err := h.read(ctx, path, version)
+
+	// A path which does not exist is not a failure of the request
+	if errors.Is(err, ErrPathNotFound) {
+		tracing.End(span, nil)
+	} else {
+		tracing.End(span, err)
+	}
+
+	return data, err
+}
+
+// Walk returns the paths of all secrets below the given path relative to it
+func (h *VaultHandler) Walk(ctx context.Context, p string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "vault.Walk", h.spanAttributes(p)...)
+	paths, err := h.walk(ctx, p)
+	tracing.End(span, err)
+	return paths, err
+}
+
+func (h *VaultHandler) walk(ctx context.Context, p string) ([]string, error) {
+	mount, version := h.kvMount(ctx, p)
+
+	var paths []string
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		listPath := path.Join(p, rel)
+		if version == KVVersion2 {
+			listPath = kvMetadataPath(mount, listPath)
+		}
+
+		keys, err := h.list(ctx, listPath)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if strings.HasSuffix(key, "/") {
+				if err := walk(path.Join(rel, key)); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			paths = append(paths, path.Join(rel, key))
+		}
+
+		return nil
+	}
+
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+// list returns the keys of a path, sub paths end with a slash
+func (h *VaultHandler) list(ctx context.Context, p string) ([]string, error) {
+	s, err := h.c.ListWithContext(ctx, p)
 	if err != nil {
 		return nil, err
 	}
 
-	// Return empty map and PathNotFound error
 	if s == nil || s.Data == nil {
-		return make(map[string]interface{}), ErrPathNotFound
+		return nil, nil
 	}
 
-	return s.Data, nil
-}
+	list, _ := s.Data["keys"].([]interface{})
+	keys := make([]string, 0, len(list))
+	for _, key := range list {
+		if k, ok := key.(string); ok {
+			keys = append(keys, k)
+		}
+	}
 
-// Setup vault client & authentication from binding
-func setupAuth(h *VaultHandler, opts AuthHandlerConfig, config *v1beta1.VaultAuthSpec) error {
-	handler := NewAuthHandler(opts)
-	method, err := registry.Invoke(config.Type, config)
+	return keys, nil
+}
 
+// read returns the data map of a path and the current kv version 2 secret version
+// which is 0 if the secret does not exist
+func (h *VaultHandler) read(ctx context.Context, path string, version int) (map[string]interface{}, int, error) {
+	s, err := h.c.ReadWithContext(ctx, path)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
-	if err := handler.Authenticate(context.TODO(), method); err != nil {
-		return err
+	// Return empty map and PathNotFound error
+	if s == nil || s.Data == nil {
+		return make(map[string]interface{}), 0, ErrPathNotFound
 	}
 
-	return nil
+	if version == KVVersion2 {
+		casVersion := kvSecretVersion(s.Data["metadata"])
+
+		// A deleted or destroyed kv version 2 secret has no data
+		data, ok := s.Data["data"].(map[string]interface{})
+		if !ok {
+			return make(map[string]interface{}), casVersion, ErrPathNotFound
+		}
+
+		return data, casVersion, nil
+	}
+
+	return s.Data, 0, nil
 }
 
-func convertTLSSpec(spec v1beta1.VaultTLSSpec) *vaultapi.TLSConfig {
-	return &vaultapi.TLSConfig{
-		CACert:        spec.CACert,
-		ClientCert:    spec.ClientCert,
-		ClientKey:     spec.ClientKey,
-		TLSServerName: spec.ServerName,
-		Insecure:      spec.Insecure,
+// Setup vault client & authentication from binding
+func setupAuth(ctx context.Context, authOpts AuthHandlerConfig, config *v1beta1.VaultAuthSpec, opts HandlerOptions) (_ *AuthHandler, err error) {
+	ctx, span := tracing.Start(ctx, "vault.setupAuth",
+		tracing.AttributeVaultAddress.String(authOpts.Address),
+		tracing.AttributeAuthType.String(config.Type),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	handler := NewAuthHandler(authOpts)
+	method, err := registry.Invoke(config.Type, config, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := handler.Authenticate(ctx, method); err != nil {
+		return nil, err
 	}
+
+	return handler, nil
 }
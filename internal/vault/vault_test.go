@@ -1,12 +1,21 @@
 package vault
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-logr/logr"
 	"github.com/hashicorp/vault/api"
 	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
 	v1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
 )
@@ -35,23 +44,97 @@ type testResult struct {
 }
 
 type mockReadWriter struct {
-	readResult  testResult
-	writeResult testResult
-	writtenPath string
-	writtenData map[string]interface{}
+	mountResult  testResult
+	mountCalls   int
+	readResult   testResult
+	writeResult  testResult
+	writeResults []testResult
+	writeCalls   int
+	readPath     string
+	writtenPath  string
+	writtenData  map[string]interface{}
+	deletedPath  string
+	listResults  map[string]testResult
 }
 
-func (rw *mockReadWriter) Read(path string) (*api.Secret, error) {
-	return rw.readResult.secret, rw.readResult.err
+func (rw *mockReadWriter) ReadWithContext(ctx context.Context, path string) (*api.Secret, error) {
+	if strings.HasPrefix(path, "sys/internal/ui/mounts/") {
+		rw.mountCalls++
+		return rw.mountResult.secret, rw.mountResult.err
+	}
+
+	rw.readPath = path
+	if rw.readResult.secret == nil {
+		return nil, rw.readResult.err
+	}
+
+	// Return a copy as vault would do, the data gets modified by the handler
+	return &api.Secret{
+		Data: copyData(rw.readResult.secret.Data),
+	}, rw.readResult.err
 }
 
-func (rw *mockReadWriter) Write(path string, data map[string]interface{}) (*api.Secret, error) {
+func copyData(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+
+	c := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if m, ok := v.(map[string]interface{}); ok {
+			v = copyData(m)
+		}
+
+		c[k] = v
+	}
+
+	return c
+}
+
+func (rw *mockReadWriter) WriteWithContext(ctx context.Context, path string, data map[string]interface{}) (*api.Secret, error) {
 	rw.writtenPath = path
 	rw.writtenData = data
+	rw.writeCalls++
+
+	if len(rw.writeResults) > 0 {
+		result := rw.writeResults[0]
+		rw.writeResults = rw.writeResults[1:]
+		return result.secret, result.err
+	}
 
 	return rw.writeResult.secret, rw.writeResult.err
 }
 
+func (rw *mockReadWriter) DeleteWithContext(ctx context.Context, path string) (*api.Secret, error) {
+	rw.deletedPath = path
+	return nil, nil
+}
+
+func (rw *mockReadWriter) ListWithContext(ctx context.Context, path string) (*api.Secret, error) {
+	result := rw.listResults[path]
+	return result.secret, result.err
+}
+
+func kv2MountResult() testResult {
+	return testResult{
+		secret: &api.Secret{
+			Data: map[string]interface{}{
+				"path": "secret/",
+				"options": map[string]interface{}{
+					"version": "2",
+				},
+			},
+		},
+	}
+}
+
+func casMismatchError() error {
+	return &api.ResponseError{
+		StatusCode: 400,
+		Errors:     []string{"check-and-set parameter did not match the current version"},
+	}
+}
+
 func TestWrite(t *testing.T) {
 	g := NewWithT(t)
 
@@ -62,6 +145,7 @@ func TestWrite(t *testing.T) {
 		readWriter    *mockReadWriter
 		expectWritten bool
 		expectError   error
+		expectPath    string
 		expectData    map[string]interface{}
 	}{
 		{
@@ -333,6 +417,173 @@ func TestWrite(t *testing.T) {
 				"carbs":     "pasta",
 			},
 		},
+		{
+			name: "Add field to existing kv version 2 path",
+			mapper: &testMapper{
+				forceApply: false,
+				path:       "/secret/food",
+				fields:     []v1beta1.FieldMapping{},
+			},
+			writeData: map[string]interface{}{
+				"fruit": "banana",
+			},
+			readWriter: &mockReadWriter{
+				mountResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"path": "secret/",
+							"options": map[string]interface{}{
+								"version": "2",
+							},
+						},
+					},
+				},
+				readResult: testResult{
+					err: nil,
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"data": map[string]interface{}{
+								"vegtable": "tomato?",
+							},
+							"metadata": map[string]interface{}{
+								"version": "1",
+							},
+						},
+					},
+				},
+				writeResult: testResult{
+					err:    nil,
+					secret: &api.Secret{},
+				},
+			},
+			expectWritten: true,
+			expectError:   nil,
+			expectPath:    "secret/data/food",
+			expectData: map[string]interface{}{
+				"data": map[string]interface{}{
+					"fruit":    "banana",
+					"vegtable": "tomato?",
+				},
+				"options": map[string]interface{}{
+					"cas": 1,
+				},
+			},
+		},
+		{
+			name: "Retry kv version 2 write if check-and-set version does not match",
+			mapper: &testMapper{
+				forceApply: false,
+				path:       "/secret/food",
+				fields:     []v1beta1.FieldMapping{},
+			},
+			writeData: map[string]interface{}{
+				"fruit": "banana",
+			},
+			readWriter: &mockReadWriter{
+				mountResult: kv2MountResult(),
+				readResult: testResult{
+					err: nil,
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"data": map[string]interface{}{
+								"vegtable": "tomato?",
+							},
+							"metadata": map[string]interface{}{
+								"version": json.Number("4"),
+							},
+						},
+					},
+				},
+				writeResults: []testResult{
+					{err: casMismatchError()},
+					{err: nil, secret: &api.Secret{}},
+				},
+			},
+			expectWritten: true,
+			expectError:   nil,
+			expectPath:    "secret/data/food",
+			expectData: map[string]interface{}{
+				"data": map[string]interface{}{
+					"fruit":    "banana",
+					"vegtable": "tomato?",
+				},
+				"options": map[string]interface{}{
+					"cas": 4,
+				},
+			},
+		},
+		{
+			name: "Fails if kv version 2 check-and-set retries are exhausted",
+			mapper: &testMapper{
+				forceApply: false,
+				path:       "/secret/food",
+				fields:     []v1beta1.FieldMapping{},
+			},
+			writeData: map[string]interface{}{
+				"fruit": "banana",
+			},
+			readWriter: &mockReadWriter{
+				mountResult: kv2MountResult(),
+				readResult: testResult{
+					err:    nil,
+					secret: nil,
+				},
+				writeResult: testResult{
+					err: casMismatchError(),
+				},
+			},
+			expectWritten: true,
+			expectError:   ErrCASMismatch,
+			expectPath:    "secret/data/food",
+			expectData: map[string]interface{}{
+				"data": map[string]interface{}{
+					"fruit": "banana",
+				},
+				"options": map[string]interface{}{
+					"cas": 0,
+				},
+			},
+		},
+		{
+			name: "Don't overwrite field on kv version 2 path with the same field",
+			mapper: &testMapper{
+				forceApply: false,
+				path:       "/secret/food",
+				fields:     []v1beta1.FieldMapping{},
+			},
+			writeData: map[string]interface{}{
+				"fruit": "banana",
+			},
+			readWriter: &mockReadWriter{
+				mountResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"path": "secret/",
+							"options": map[string]interface{}{
+								"version": "2",
+							},
+						},
+					},
+				},
+				readResult: testResult{
+					err: nil,
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"data": map[string]interface{}{
+								"fruit": "banana",
+							},
+						},
+					},
+				},
+				writeResult: testResult{
+					err:    nil,
+					secret: &api.Secret{},
+				},
+			},
+			expectWritten: false,
+			expectError:   nil,
+			expectData:    nil,
+		},
 		{
 			name: "return error if read fails",
 			mapper: &testMapper{
@@ -366,19 +617,567 @@ func TestWrite(t *testing.T) {
 				c:      test.readWriter,
 			}
 
-			written, err := handler.Write(test.mapper, test.writeData)
+			result, err := handler.Write(context.Background(), test.mapper, test.writeData)
 			if test.expectError == nil {
 				g.Expect(err).NotTo(HaveOccurred(), "write error occurd but should not")
 			} else {
 				g.Expect(err).To(Equal(test.expectError))
 			}
 
-			g.Expect(written).To(Equal(test.expectWritten))
+			g.Expect(result.Written).To(Equal(test.expectWritten))
 
 			if test.expectWritten == true {
+				expectPath := test.expectPath
+				if expectPath == "" {
+					expectPath = test.mapper.GetPath()
+				}
+
 				g.Expect(test.readWriter.writtenData).To(Equal(test.expectData))
-				g.Expect(test.readWriter.writtenPath).To(Equal(test.mapper.GetPath()))
+				g.Expect(test.readWriter.writtenPath).To(Equal(expectPath))
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		kvVersion   int
+		readWriter  *mockReadWriter
+		expectPath  string
+		expectError error
+		expectData  map[string]interface{}
+	}{
+		{
+			name: "read kv version 1 path",
+			path: "/secret/food",
+			readWriter: &mockReadWriter{
+				readResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"fruit": "banana",
+						},
+					},
+				},
+			},
+			expectPath: "/secret/food",
+			expectData: map[string]interface{}{
+				"fruit": "banana",
+			},
+		},
+		{
+			name: "read and unwrap detected kv version 2 path",
+			path: "/secret/food",
+			readWriter: &mockReadWriter{
+				mountResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"path": "secret/",
+							"options": map[string]interface{}{
+								"version": "2",
+							},
+						},
+					},
+				},
+				readResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"data": map[string]interface{}{
+								"fruit": "banana",
+							},
+							"metadata": map[string]interface{}{
+								"version": "3",
+							},
+						},
+					},
+				},
+			},
+			expectPath: "secret/data/food",
+			expectData: map[string]interface{}{
+				"fruit": "banana",
+			},
+		},
+		{
+			name:      "read kv version 2 path if the version is explicitly set",
+			path:      "/kv/food",
+			kvVersion: KVVersion2,
+			readWriter: &mockReadWriter{
+				mountResult: testResult{
+					err: errors.New("permission denied"),
+				},
+				readResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"data": map[string]interface{}{
+								"fruit": "banana",
+							},
+						},
+					},
+				},
+			},
+			expectPath: "kv/data/food",
+			expectData: map[string]interface{}{
+				"fruit": "banana",
+			},
+		},
+		{
+			name: "return path not found if kv version 2 secret is deleted",
+			path: "/secret/food",
+			readWriter: &mockReadWriter{
+				mountResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"path": "secret/",
+							"options": map[string]interface{}{
+								"version": "2",
+							},
+						},
+					},
+				},
+				readResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"data": nil,
+							"metadata": map[string]interface{}{
+								"deletion_time": "2021-01-01T00:00:00Z",
+							},
+						},
+					},
+				},
+			},
+			expectPath:  "secret/data/food",
+			expectError: ErrPathNotFound,
+			expectData:  map[string]interface{}{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := &VaultHandler{
+				logger:    logr.Discard(),
+				c:         test.readWriter,
+				kvVersion: test.kvVersion,
+			}
+
+			data, err := handler.Read(context.Background(), test.path)
+			if test.expectError == nil {
+				g.Expect(err).NotTo(HaveOccurred(), "read error occurd but should not")
+			} else {
+				g.Expect(err).To(Equal(test.expectError))
+			}
+
+			g.Expect(data).To(Equal(test.expectData))
+			g.Expect(test.readWriter.readPath).To(Equal(test.expectPath))
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name              string
+		path              string
+		fields            []string
+		readWriter        *mockReadWriter
+		expectRemoved     []string
+		expectError       error
+		expectData        map[string]interface{}
+		expectDeletedPath string
+	}{
+		{
+			name:   "remove field and keep other fields",
+			fields: []string{"fruit"},
+			readWriter: &mockReadWriter{
+				readResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"fruit":     "banana",
+							"vegetable": "carrot",
+						},
+					},
+				},
+			},
+			expectRemoved: []string{"fruit"},
+			expectData: map[string]interface{}{
+				"vegetable": "carrot",
+			},
+		},
+		{
+			name:   "delete path if no fields are left",
+			fields: []string{"fruit"},
+			readWriter: &mockReadWriter{
+				readResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"fruit": "banana",
+						},
+					},
+				},
+			},
+			expectRemoved:     []string{"fruit"},
+			expectDeletedPath: "/food",
+		},
+		{
+			name:   "delete kv version 2 path if no fields are left",
+			path:   "/secret/food",
+			fields: []string{"fruit"},
+			readWriter: &mockReadWriter{
+				mountResult: kv2MountResult(),
+				readResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"data": map[string]interface{}{
+								"fruit": "banana",
+							},
+						},
+					},
+				},
+			},
+			expectRemoved:     []string{"fruit"},
+			expectDeletedPath: "secret/data/food",
+		},
+		{
+			name:   "nothing to remove if fields do not exist",
+			fields: []string{"fruit"},
+			readWriter: &mockReadWriter{
+				readResult: testResult{
+					secret: &api.Secret{
+						Data: map[string]interface{}{
+							"vegetable": "carrot",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:   "nothing to remove if path does not exist",
+			fields: []string{"fruit"},
+			readWriter: &mockReadWriter{
+				readResult: testResult{
+					secret: nil,
+				},
+			},
+		},
+		{
+			name:   "return error if read fails",
+			fields: []string{"fruit"},
+			readWriter: &mockReadWriter{
+				readResult: testResult{
+					err: errors.New("read fails"),
+				},
+			},
+			expectError: errors.New("read fails"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := &VaultHandler{
+				logger: logr.Discard(),
+				c:      test.readWriter,
 			}
+
+			path := test.path
+			if path == "" {
+				path = "/food"
+			}
+
+			removed, err := handler.Remove(context.Background(), path, test.fields)
+			if test.expectError == nil {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(Equal(test.expectError))
+			}
+
+			g.Expect(removed).To(Equal(test.expectRemoved))
+			g.Expect(test.readWriter.writtenData).To(Equal(test.expectData))
+			g.Expect(test.readWriter.deletedPath).To(Equal(test.expectDeletedPath))
 		})
 	}
 }
+
+func TestWriteFields(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name          string
+		forceApply    bool
+		fields        []v1beta1.FieldMapping
+		expectFields  []string
+		expectAdded   []string
+		expectUpdated []string
+		expectSkipped []string
+	}{
+		{
+			name:          "existing fields with a different value are not managed",
+			expectFields:  []string{"fruit", "vegetable"},
+			expectAdded:   []string{"fruit"},
+			expectSkipped: []string{"nut"},
+		},
+		{
+			name:          "overwritten fields are managed with force apply",
+			forceApply:    true,
+			expectFields:  []string{"fruit", "nut", "vegetable"},
+			expectAdded:   []string{"fruit"},
+			expectUpdated: []string{"nut"},
+		},
+		{
+			name: "renamed fields are reported with the destination name",
+			fields: []v1beta1.FieldMapping{
+				{
+					Name:   "fruit",
+					Rename: "berry",
+				},
+			},
+			expectFields: []string{"berry"},
+			expectAdded:  []string{"berry"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := &VaultHandler{
+				logger: logr.Discard(),
+				c: &mockReadWriter{
+					readResult: testResult{
+						secret: &api.Secret{
+							Data: map[string]interface{}{
+								"vegetable": "carrot",
+								"nut":       "walnut",
+							},
+						},
+					},
+				},
+			}
+
+			result, err := handler.Write(context.Background(), &testMapper{
+				forceApply: test.forceApply,
+				path:       "/food",
+				fields:     test.fields,
+			}, map[string]interface{}{
+				"fruit":     "banana",
+				"vegetable": "carrot",
+				"nut":       "peanut",
+			})
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result.Fields).To(Equal(test.expectFields))
+			g.Expect(result.Added).To(Equal(test.expectAdded))
+			g.Expect(result.Updated).To(Equal(test.expectUpdated))
+			g.Expect(result.Skipped).To(Equal(test.expectSkipped))
+		})
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	g := NewWithT(t)
+
+	handler := &VaultHandler{
+		logger: logr.Discard(),
+		c: &mockReadWriter{
+			mountResult: kv2MountResult(),
+			readResult: testResult{
+				secret: &api.Secret{
+					Data: map[string]interface{}{
+						"data": map[string]interface{}{
+							"vegetable": "carrot",
+						},
+						"metadata": map[string]interface{}{
+							"version": json.Number("3"),
+						},
+					},
+				},
+			},
+			writeResult: testResult{
+				secret: &api.Secret{
+					Data: map[string]interface{}{
+						"version": json.Number("4"),
+					},
+				},
+			},
+		},
+	}
+
+	result, err := handler.Write(context.Background(), &testMapper{
+		path: "secret/write-result",
+	}, map[string]interface{}{
+		"fruit": "banana",
+	})
+
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.Written).To(BeTrue())
+	g.Expect(result.Path).To(Equal("secret/data/write-result"))
+	g.Expect(result.KVVersion).To(Equal(KVVersion2))
+	g.Expect(result.Version).To(Equal(4))
+
+	// The hash only covers the fields written by the mapper
+	expectHash, err := DataHash(map[string]interface{}{"fruit": "banana"}, []string{"fruit"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.DataHash).To(Equal(expectHash))
+	g.Expect(result.DataHash).To(HaveLen(64))
+
+	// The field did not exist before the write
+	expectPreviousHash, err := DataHash(map[string]interface{}{}, []string{"fruit"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.PreviousHash).To(Equal(expectPreviousHash))
+	g.Expect(result.Added).To(Equal([]string{"fruit"}))
+}
+
+func listResult(keys ...interface{}) testResult {
+	return testResult{
+		secret: &api.Secret{
+			Data: map[string]interface{}{
+				"keys": keys,
+			},
+		},
+	}
+}
+
+func TestWalk(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		readWriter  *mockReadWriter
+		expectPaths []string
+		expectError error
+	}{
+		{
+			name: "walk kv version 1 tree",
+			path: "/secret/app",
+			readWriter: &mockReadWriter{
+				listResults: map[string]testResult{
+					"/secret/app":        listResult("db", "api/"),
+					"/secret/app/api":    listResult("token", "v2/"),
+					"/secret/app/api/v2": listResult("key"),
+				},
+			},
+			expectPaths: []string{"db", "api/token", "api/v2/key"},
+		},
+		{
+			name: "walk kv version 2 tree using metadata paths",
+			path: "/secret/app",
+			readWriter: &mockReadWriter{
+				mountResult: kv2MountResult(),
+				listResults: map[string]testResult{
+					"secret/metadata/app":     listResult("db", "api/"),
+					"secret/metadata/app/api": listResult("token"),
+				},
+			},
+			expectPaths: []string{"db", "api/token"},
+		},
+		{
+			name: "empty tree",
+			path: "/secret/app",
+			readWriter: &mockReadWriter{
+				listResults: map[string]testResult{},
+			},
+			expectPaths: nil,
+		},
+		{
+			name: "return error if list fails",
+			path: "/secret/app",
+			readWriter: &mockReadWriter{
+				listResults: map[string]testResult{
+					"/secret/app":     listResult("api/"),
+					"/secret/app/api": {err: errors.New("list fails")},
+				},
+			},
+			expectError: errors.New("list fails"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := &VaultHandler{
+				logger: logr.Discard(),
+				c:      test.readWriter,
+			}
+
+			paths, err := handler.Walk(context.Background(), test.path)
+			if test.expectError == nil {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(Equal(test.expectError))
+			}
+
+			g.Expect(paths).To(Equal(test.expectPaths))
+		})
+	}
+}
+
+func TestWriteSpan(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(provider)
+
+	handler := &VaultHandler{
+		logger: logr.Discard(),
+		c: &mockReadWriter{
+			readResult: testResult{
+				secret: &api.Secret{
+					Data: map[string]interface{}{},
+				},
+			},
+		},
+	}
+
+	_, err := handler.Write(context.Background(), &testMapper{
+		path: "/food",
+	}, map[string]interface{}{
+		"fruit": "banana",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(1))
+	g.Expect(spans[0].Name()).To(Equal("vault.Write"))
+	g.Expect(spans[0].Attributes()).To(ContainElement(attribute.String("vault.path", "/food")))
+
+	// Secret values must never be part of a span
+	for _, attr := range spans[0].Attributes() {
+		g.Expect(attr.Value.Emit()).NotTo(ContainSubstring("banana"))
+	}
+}
+
+func TestRequestSpans(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(provider)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"fruit":"banana"}}`))
+	}))
+	defer server.Close()
+
+	vaultClient, cfg, err := newClient(&v1beta1.VaultSpec{Address: server.URL}, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	handler := &VaultHandler{
+		logger:    logr.Discard(),
+		cfg:       cfg,
+		kvVersion: KVVersion1,
+		c:         vaultClient.Logical(),
+	}
+
+	_, err = handler.Read(context.Background(), "/food")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The http request is a child span of the read
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(2))
+	g.Expect(spans[0].Name()).To(Equal("HTTP GET"))
+	g.Expect(spans[1].Name()).To(Equal("vault.Read"))
+	g.Expect(spans[0].Parent().SpanID()).To(Equal(spans[1].SpanContext().SpanID()))
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 	"strings"
@@ -31,9 +32,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	infradoodlecomv1beta1 "github.com/DoodleScheduling/k8svault-controller/api/v1beta1"
 	"github.com/DoodleScheduling/k8svault-controller/controllers"
+	"github.com/DoodleScheduling/k8svault-controller/internal/audit"
+	"github.com/DoodleScheduling/k8svault-controller/internal/tracing"
+	"github.com/DoodleScheduling/k8svault-controller/internal/vault"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -57,6 +62,13 @@ var (
 	leaderElectionNamespace string
 	namespaces              = ""
 	concurrent              = 4
+	otlpEndpoint            = ""
+	otlpInsecure            = false
+	traceSampleRatio        = 1.0
+	auditLog                = ""
+	controllerNamespace     = ""
+	hashKey                 = ""
+	tokenRequestAudiences   = ""
 )
 
 func main() {
@@ -71,6 +83,20 @@ func main() {
 		"The controller listens by default for all namespaces. This may be limited to a comma delimted list of dedicated namespaces.")
 	flag.IntVar(&concurrent, "concurrent", 4,
 		"The number of concurrent reconcile workers. By default this is 4.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "",
+		"The host and port of an OTLP http receiver traces are exported to, for example otel-collector:4318. By default OTEL_EXPORTER_OTLP_ENDPOINT is used, tracing is disabled if neither is set.")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false,
+		"Export traces to the OTLP receiver without TLS.")
+	flag.Float64Var(&traceSampleRatio, "trace-sample-ratio", 1.0,
+		"The ratio of sampled traces between 0 and 1. By default all traces are sampled.")
+	flag.StringVar(&auditLog, "audit-log", "",
+		"Write an audit record for each change to vault as JSON line to stdout or to the given file. Disabled if not set.")
+	flag.StringVar(&controllerNamespace, "controller-namespace", "",
+		"The namespace the controller runs in. Secrets, config maps and service accounts referenced by a ClusterVaultConnection are resolved in it.")
+	flag.StringVar(&tokenRequestAudiences, "token-request-audiences", "",
+		"A comma delimited list of audiences service account tokens may be requested for with jwt authentication. Requesting tokens is disabled if not set.")
+	flag.StringVar(&hashKey, "hash-key", "",
+		"The key of the hashes of secret values reported in the status and the audit log. Required for hashes which stay comparable across restarts, if not set a random key is generated on startup.")
 
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
@@ -106,6 +132,11 @@ func main() {
 		setupLog.Info("watching all namespaces")
 	}
 
+	var audiences []string
+	if v := viper.GetString("token-request-audiences"); v != "" {
+		audiences = strings.Split(v, ",")
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), opts)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -126,11 +157,82 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Spans are flushed once the manager stops
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Endpoint:    viper.GetString("otlp-endpoint"),
+		Insecure:    viper.GetBool("otlp-insecure"),
+		SampleRatio: viper.GetFloat64("trace-sample-ratio"),
+	})
+	if err != nil {
+		setupLog.Error(err, "Could not setup tracing")
+		os.Exit(1)
+	}
+
+	err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "failed to flush traces")
+		}
+
+		return nil
+	}))
+	if err != nil {
+		setupLog.Error(err, "Could not add tracing")
+		os.Exit(1)
+	}
+
+	// A fixed key keeps the hashes comparable across restarts
+	if key := viper.GetString("hash-key"); key != "" {
+		vault.SetHashKey([]byte(key))
+	} else {
+		setupLog.Info("no hash key configured, using a random key, hashes from before a restart do not match anymore")
+	}
+
+	// Authenticated vault clients are shared between all reconcilers
+	// Tokens get revoked once the manager stops
+	clientCache := vault.NewClientCache()
+	err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		if err := clientCache.Close(); err != nil {
+			setupLog.Error(err, "failed to revoke vault tokens")
+		}
+
+		return nil
+	}))
+	if err != nil {
+		setupLog.Error(err, "Could not add vault client cache")
+		os.Exit(1)
+	}
+
+	// Audit records are written until the manager stops
+	var auditSink audit.Sink
+	if target := viper.GetString("audit-log"); target != "" {
+		sink, err := audit.Open(target)
+		if err != nil {
+			setupLog.Error(err, "Could not open audit log", "target", target)
+			os.Exit(1)
+		}
+
+		auditSink = sink
+		err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return sink.Close()
+		}))
+		if err != nil {
+			setupLog.Error(err, "Could not add audit log")
+			os.Exit(1)
+		}
+	}
+
 	vbReconciler := &controllers.VaultBindingReconciler{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("VaultBinding"),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("VaultBinding"),
+		Client:                mgr.GetClient(),
+		Log:                   ctrl.Log.WithName("controllers").WithName("VaultBinding"),
+		Scheme:                mgr.GetScheme(),
+		Recorder:              mgr.GetEventRecorderFor("VaultBinding"),
+		ClientCache:           clientCache,
+		AuditSink:             auditSink,
+		ControllerNamespace:   viper.GetString("controller-namespace"),
+		TokenRequestAudiences: audiences,
 	}
 	if err = vbReconciler.SetupWithManager(mgr, controllers.VaultBindingReconcilerOptions{MaxConcurrentReconciles: viper.GetInt("concurrent")}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "VaultBinding")
@@ -138,16 +240,57 @@ func main() {
 	}
 
 	vmReconciler := &controllers.VaultMirrorReconciler{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("VaultMirror"),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("VaultMirror"),
+		Client:                mgr.GetClient(),
+		Log:                   ctrl.Log.WithName("controllers").WithName("VaultMirror"),
+		Scheme:                mgr.GetScheme(),
+		Recorder:              mgr.GetEventRecorderFor("VaultMirror"),
+		ClientCache:           clientCache,
+		AuditSink:             auditSink,
+		ControllerNamespace:   viper.GetString("controller-namespace"),
+		TokenRequestAudiences: audiences,
 	}
 	if err = vmReconciler.SetupWithManager(mgr, controllers.VaultMirrorReconcilerOptions{MaxConcurrentReconciles: viper.GetInt("concurrent")}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "VaultMirror")
 		os.Exit(1)
 	}
 
+	vsReconciler := &controllers.VaultSecretReconciler{
+		Client:                mgr.GetClient(),
+		Log:                   ctrl.Log.WithName("controllers").WithName("VaultSecret"),
+		Scheme:                mgr.GetScheme(),
+		Recorder:              mgr.GetEventRecorderFor("VaultSecret"),
+		ClientCache:           clientCache,
+		ControllerNamespace:   viper.GetString("controller-namespace"),
+		TokenRequestAudiences: audiences,
+	}
+	if err = vsReconciler.SetupWithManager(mgr, controllers.VaultSecretReconcilerOptions{MaxConcurrentReconciles: viper.GetInt("concurrent")}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VaultSecret")
+		os.Exit(1)
+	}
+
+	vcReconciler := &controllers.VaultConnectionReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("VaultConnection"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("VaultConnection"),
+	}
+	if err = vcReconciler.SetupWithManager(mgr, controllers.VaultConnectionReconcilerOptions{MaxConcurrentReconciles: viper.GetInt("concurrent")}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VaultConnection")
+		os.Exit(1)
+	}
+
+	cvcReconciler := &controllers.ClusterVaultConnectionReconciler{
+		Client:              mgr.GetClient(),
+		Log:                 ctrl.Log.WithName("controllers").WithName("ClusterVaultConnection"),
+		Scheme:              mgr.GetScheme(),
+		Recorder:            mgr.GetEventRecorderFor("ClusterVaultConnection"),
+		ControllerNamespace: viper.GetString("controller-namespace"),
+	}
+	if err = cvcReconciler.SetupWithManager(mgr, controllers.ClusterVaultConnectionReconcilerOptions{MaxConcurrentReconciles: viper.GetInt("concurrent")}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterVaultConnection")
+		os.Exit(1)
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -24,3 +24,5 @@ kubectl -n k8svault-system wait vaultbinding/test-secret --for=condition=Bound -
 kubectl -n k8svault-system exec -i sts/vault vault -- vault read /secret/example
 kubectl -n k8svault-system wait vaultmirror/test-secret --for=condition=Bound --timeout=1m
 kubectl -n k8svault-system exec -i sts/vault vault -- vault read /secret/example-copy
+kubectl -n k8svault-system wait vaultsecret/test-secret-from-vault --for=condition=Bound --timeout=1m
+kubectl -n k8svault-system get secret test-secret-from-vault -o yaml